@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// composeStack starts and stops docker-compose.yml services for the compose
+// target. Every server shares SERVER_CPUSET, so only the service being
+// measured may run; idle servers would otherwise compete for the same CPUs.
+type composeStack struct {
+	root string
+	file string
+}
+
+// newComposeStack returns nil when the docker CLI is unavailable, in which
+// case the suite expects the services to be running already.
+func newComposeStack(root string) *composeStack {
+	if _, err := exec.LookPath("docker"); err != nil {
+		log.Printf("WARNING: docker not found; expecting compose services to be running and isolated already")
+		return nil
+	}
+	return &composeStack{root: root, file: filepath.Join(root, "docker-compose.yml")}
+}
+
+func (c *composeStack) compose(ctx context.Context, args ...string) error {
+	// The wildcard profile lets services be named whichever profile they
+	// belong to.
+	args = append([]string{"compose", "--file", c.file, "--profile", "*"}, args...)
+	return runQuiet(ctx, c.root, "docker", args...)
+}
+
+// up starts one service from its prebuilt image without its dependencies.
+func (c *composeStack) up(ctx context.Context, service string) error {
+	return c.compose(ctx, "up", "--detach", "--no-build", "--no-deps", service)
+}
+
+// stop stops services even after the suite's context is cancelled, so an
+// interrupted run does not leave a server holding the CPU set.
+func (c *composeStack) stop(services ...string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.compose(ctx, append([]string{"stop"}, services...)...); err != nil {
+		log.Printf("WARNING: %v", err)
+	}
+}
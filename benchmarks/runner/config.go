@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Duration wraps time.Duration so config files can use "10s" style values.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// Load describes how hard a scenario is driven.
+type Load struct {
+	Connections int      `json:"connections"`
+	Duration    Duration `json:"duration"`
+	Pipeline    int      `json:"pipeline"`
+	Warmup      Duration `json:"warmup"`
+	WarmupJIT   Duration `json:"warmup_jit"`
+}
+
+// Scenario is a single request shape sent to every server that supports it.
+type Scenario struct {
+	Name         string            `json:"name"`
+	Method       string            `json:"method,omitempty"`
+	Paths        []string          `json:"paths"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	ExpectStatus int               `json:"expect_status,omitempty"`
+	// BodySize sends a generated body of that many bytes when Body is empty.
+	BodySize int `json:"body_size,omitempty"`
+	// Servers restricts the scenario to the named servers. Empty means all.
+	Servers []string `json:"servers,omitempty"`
+}
+
+func (s Scenario) body() string {
+	if s.Body == "" && s.BodySize > 0 {
+		return strings.Repeat("x", s.BodySize)
+	}
+	return s.Body
+}
+
+func (s Scenario) supports(server string) bool {
+	if len(s.Servers) == 0 {
+		return true
+	}
+	for _, name := range s.Servers {
+		if name == server {
+			return true
+		}
+	}
+	return false
+}
+
+// serversExcept lists every known server apart from the named ones.
+func serversExcept(skip ...string) []string {
+	var out []string
+	for _, name := range serverNames() {
+		if !slices.Contains(skip, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// Targets the runner can benchmark: servers it builds and starts itself, or
+// the already-running docker-compose stack, addressed by service name.
+const (
+	targetLocal   = "local"
+	targetCompose = "compose"
+)
+
+// Config is the full harness configuration. Flags override file values.
+type Config struct {
+	Servers  []string `json:"servers"`
+	Modes    []string `json:"modes"`
+	Runs     int      `json:"runs"`
+	Target   string   `json:"target"`
+	Host     string   `json:"host"`
+	Baseline string   `json:"baseline"`
+	// Profile starts servers with BENCH_PROFILE=1 and records memory, GC,
+	// and allocation figures for every run.
+	Profile   bool       `json:"profile"`
+	OutDir    string     `json:"out_dir,omitempty"`
+	Load      Load       `json:"load"`
+	Scenarios []Scenario `json:"scenarios"`
+	// Regression compares results with an earlier report. SQLite, when
+	// set, also appends every suite to that database.
+	Regression Regression `json:"regression"`
+	SQLite     string     `json:"sqlite,omitempty"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Servers:  serverNames(),
+		Modes:    []string{modeAOT, modeJIT},
+		Runs:     3,
+		Target:   targetLocal,
+		Host:     "127.0.0.1",
+		Baseline: "dart_io",
+		Profile:  true,
+		Regression: Regression{
+			MaxRPSDrop: 10,
+			MaxP99Rise: 20,
+		},
+		Load: Load{
+			Connections: 100,
+			Duration:    Duration{10 * time.Second},
+			Pipeline:    1,
+			Warmup:      Duration{5 * time.Second},
+			WarmupJIT:   Duration{10 * time.Second},
+		},
+		Scenarios: []Scenario{
+			{Name: "plaintext", Paths: []string{"/"}},
+			{Name: "json", Paths: []string{"/json"}},
+			{Name: "deep-static", Paths: []string{"/deep/a/b/c/d/e/f/g/h"}},
+			{Name: "single-param", Paths: []string{"/param/1", "/param/42", "/param/routed"}},
+			{Name: "five-param", Paths: []string{"/params/1/2/3/4/5", "/params/a/b/c/d/e"}},
+			{
+				Name:  "catch-all",
+				Paths: []string{"/static/app.css", "/static/js/vendor/lib.min.js", "/static/img/a/b/c/logo.png"},
+			},
+			{
+				// Misses never overshoot a trailing parameter: Echo lets
+				// `/param/:id` match `/param/1/extra`.
+				Name:         "not-found",
+				Paths:        []string{"/missing", "/deep/a/b/c/x", "/deep/a/b/c/d/e/f/g/h/i", "/params/1/2/3"},
+				ExpectStatus: 404,
+			},
+			{
+				Name:    "echo",
+				Method:  "POST",
+				Paths:   []string{"/echo"},
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    `{"id":42,"name":"routed","tags":["http","router","dart"],"owner":{"login":"kingwill101","site_admin":false},"stars":1024,"score":0.87}`,
+			},
+			{
+				Name:     "upload",
+				Method:   "POST",
+				Paths:    []string{"/upload"},
+				Headers:  map[string]string{"Content-Type": "application/octet-stream"},
+				BodySize: 1 << 20,
+			},
+			{Name: "download", Paths: []string{"/download"}},
+			{
+				Name: "github",
+				Paths: []string{
+					"/user/repos",
+					"/repos/kingwill101/routed",
+					"/repos/kingwill101/routed/issues/42/comments",
+					"/repos/kingwill101/routed/git/refs/heads/main",
+					"/users/kingwill101/following/octocat",
+					"/legacy/issues/search/kingwill101/routed/open/router",
+					"/orgs/dart-lang/public_members/kingwill101",
+					"/gitignore/templates/Go",
+				},
+				// Serinus does not register the shared table; see
+				// servers/go/cmd/genroutes.
+				Servers: serversExcept("serinus"),
+			},
+		},
+	}
+}
+
+// loadConfig reads a JSON config file on top of the defaults. Fields absent
+// from the file keep their default values.
+func loadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	if c.Runs < 1 {
+		return fmt.Errorf("runs must be at least 1")
+	}
+	if c.Load.Connections < 1 {
+		return fmt.Errorf("connections must be at least 1")
+	}
+	if c.Load.Pipeline < 1 {
+		return fmt.Errorf("pipeline depth must be at least 1")
+	}
+	if c.Load.Duration.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if c.Regression.MaxRPSDrop < 0 || c.Regression.MaxP99Rise < 0 {
+		return fmt.Errorf("regression thresholds must not be negative")
+	}
+	if c.Target != targetLocal && c.Target != targetCompose {
+		return fmt.Errorf("unknown target %q (want %s or %s)", c.Target, targetLocal, targetCompose)
+	}
+	for _, name := range c.Servers {
+		if _, ok := lookupServer(name); !ok {
+			return fmt.Errorf("unknown server %q (known: %s)", name, strings.Join(serverNames(), ", "))
+		}
+	}
+	for _, mode := range c.Modes {
+		if mode != modeAOT && mode != modeJIT {
+			return fmt.Errorf("unknown mode %q (want %s or %s)", mode, modeAOT, modeJIT)
+		}
+	}
+	for i := range c.Scenarios {
+		sc := &c.Scenarios[i]
+		if sc.Name == "" {
+			return fmt.Errorf("scenario %d has no name", i)
+		}
+		if len(sc.Paths) == 0 {
+			return fmt.Errorf("scenario %q has no paths", sc.Name)
+		}
+		if sc.Method == "" {
+			sc.Method = "GET"
+		}
+		if sc.ExpectStatus == 0 {
+			sc.ExpectStatus = 200
+		}
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,3 @@
+module benchmarks/runner
+
+go 1.22
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits controls histogram precision: each power-of-two range is
+// split into 2^subBucketBits linear buckets, giving roughly 3% relative error.
+const (
+	subBucketBits  = 5
+	subBucketCount = 1 << subBucketBits
+	bucketCount    = (64 - subBucketBits + 1) * subBucketCount
+)
+
+// histogram is a fixed-size log-linear latency histogram in microseconds.
+// Each load worker owns one, so recording needs no synchronisation; workers
+// are merged once the run completes.
+type histogram struct {
+	counts [bucketCount]uint64
+	total  uint64
+	sum    uint64
+	min    uint64
+	max    uint64
+}
+
+func newHistogram() *histogram {
+	return &histogram{min: math.MaxUint64}
+}
+
+func bucketIndex(v uint64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+	n := bits.Len64(v)
+	shift := n - subBucketBits - 1
+	top := v >> uint(shift)
+	return (shift+1)*subBucketCount + int(top) - subBucketCount
+}
+
+// bucketValue returns the midpoint of the bucket at index.
+func bucketValue(index int) uint64 {
+	if index < subBucketCount {
+		return uint64(index)
+	}
+	group := index / subBucketCount
+	top := uint64(index%subBucketCount + subBucketCount)
+	shift := uint(group - 1)
+	lower := top << shift
+	upper := ((top + 1) << shift) - 1
+	return lower + (upper-lower)/2
+}
+
+func (h *histogram) record(d time.Duration) {
+	v := uint64(d / time.Microsecond)
+	h.counts[bucketIndex(v)]++
+	h.total++
+	h.sum += v
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+func (h *histogram) merge(other *histogram) {
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.total += other.total
+	h.sum += other.sum
+	if other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+func (h *histogram) mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum/h.total) * time.Microsecond
+}
+
+// percentile returns the latency at or below which p percent of samples fall.
+func (h *histogram) percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(p / 100 * float64(h.total)))
+	if target == 0 {
+		target = 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= target {
+			v := bucketValue(i)
+			if v > h.max {
+				v = h.max
+			}
+			return time.Duration(v) * time.Microsecond
+		}
+	}
+	return time.Duration(h.max) * time.Microsecond
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketIndexRoundTrip(t *testing.T) {
+	for _, v := range []uint64{0, 1, 31, 32, 33, 63, 64, 100, 1000, 12345, 1 << 20, 1<<40 + 7} {
+		i := bucketIndex(v)
+		if i < 0 || i >= bucketCount {
+			t.Fatalf("bucketIndex(%d) = %d, out of range", v, i)
+		}
+		got := bucketValue(i)
+		if v < subBucketCount {
+			if got != v {
+				t.Errorf("bucketValue(bucketIndex(%d)) = %d, want exact", v, got)
+			}
+			continue
+		}
+		// Each bucket spans 1/subBucketCount of its power of two.
+		if diff := float64(got) - float64(v); diff > float64(v)/subBucketCount || -diff > float64(v)/subBucketCount {
+			t.Errorf("bucketValue(bucketIndex(%d)) = %d, error above %d%%", v, got, 100/subBucketCount)
+		}
+	}
+}
+
+func TestBucketIndexMonotonic(t *testing.T) {
+	prev := bucketIndex(0)
+	for v := uint64(1); v < 1<<16; v++ {
+		i := bucketIndex(v)
+		if i < prev {
+			t.Fatalf("bucketIndex(%d) = %d, below bucketIndex(%d) = %d", v, i, v-1, prev)
+		}
+		prev = i
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	h := newHistogram()
+	if got := h.percentile(99); got != 0 {
+		t.Fatalf("empty percentile = %v, want 0", got)
+	}
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+	for _, tc := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{50, 500 * time.Microsecond},
+		{90, 900 * time.Microsecond},
+		{99, 990 * time.Microsecond},
+		{100, 1000 * time.Microsecond},
+	} {
+		got := h.percentile(tc.p)
+		if diff := got - tc.want; diff > tc.want/subBucketCount || -diff > tc.want/subBucketCount {
+			t.Errorf("percentile(%v) = %v, want about %v", tc.p, got, tc.want)
+		}
+	}
+	if got := h.percentile(100); got > time.Millisecond {
+		t.Errorf("percentile(100) = %v, above the recorded max", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b := newHistogram(), newHistogram()
+	a.record(10 * time.Microsecond)
+	b.record(30 * time.Microsecond)
+	b.record(20 * time.Microsecond)
+	a.merge(b)
+	if a.total != 3 || a.min != 10 || a.max != 30 {
+		t.Fatalf("merged total/min/max = %d/%d/%d, want 3/10/30", a.total, a.min, a.max)
+	}
+	if got := a.mean(); got != 20*time.Microsecond {
+		t.Errorf("mean = %v, want 20µs", got)
+	}
+}
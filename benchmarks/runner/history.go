@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Regression configures the comparison against an earlier report.
+type Regression struct {
+	// Against is the report to compare with. Empty means the newest
+	// runner-*.json already in the output directory; "none" disables it.
+	Against string `json:"against,omitempty"`
+	// MaxRPSDrop and MaxP99Rise are the tolerated changes in percent.
+	MaxRPSDrop float64 `json:"max_rps_drop_pct"`
+	MaxP99Rise float64 `json:"max_p99_rise_pct"`
+	// Fail makes the runner exit non-zero when any result regresses.
+	Fail bool `json:"fail"`
+}
+
+// comparison is the regression check recorded in a report. LoadChanged is
+// set when the suites ran with a different target, load, run count, or
+// profiling setting.
+type comparison struct {
+	Against     string    `json:"against"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Commit      string    `json:"commit,omitempty"`
+	LoadChanged bool      `json:"load_changed,omitempty"`
+	Deltas      []delta   `json:"deltas"`
+	Regressions int       `json:"regressions"`
+}
+
+// delta compares one server/mode/scenario between the two reports. Changes
+// are percentages relative to the baseline; error rates are the percentage
+// of requests that failed or got an unexpected status.
+type delta struct {
+	Server       string  `json:"server"`
+	Mode         string  `json:"mode,omitempty"`
+	Scenario     string  `json:"scenario"`
+	BaseRPS      float64 `json:"base_rps"`
+	RPS          float64 `json:"rps"`
+	RPSChange    float64 `json:"rps_change_pct"`
+	BaseP99Ms    float64 `json:"base_p99_ms"`
+	P99Ms        float64 `json:"p99_ms"`
+	P99Change    float64 `json:"p99_change_pct"`
+	BaseErrorPct float64 `json:"base_error_pct"`
+	ErrorPct     float64 `json:"error_pct"`
+	// Error is set when the result failed outright this time.
+	Error string `json:"error,omitempty"`
+	// ScenarioChanged marks a scenario whose definition differs from the
+	// baseline's, so its numbers are not like for like.
+	ScenarioChanged bool   `json:"scenario_changed,omitempty"`
+	Regression      string `json:"regression,omitempty"`
+}
+
+func (d delta) label() string {
+	r := result{Server: d.Server, Mode: d.Mode}
+	return r.label()
+}
+
+// previousReport finds the report to compare with. It returns nil without
+// an error when there is nothing to compare against yet.
+func previousReport(dir string, reg Regression) (*report, string, error) {
+	path := reg.Against
+	switch path {
+	case "none":
+		return nil, "", nil
+	case "":
+		matches, err := filepath.Glob(filepath.Join(dir, "runner-*.json"))
+		if err != nil {
+			return nil, "", err
+		}
+		// Timestamped names sort chronologically; take the newest report
+		// that ran to completion.
+		sort.Strings(matches)
+		for i := len(matches) - 1; i >= 0; i-- {
+			prev, err := readReport(matches[i])
+			if err != nil {
+				return nil, "", err
+			}
+			if !prev.Interrupted {
+				return prev, matches[i], nil
+			}
+		}
+		return nil, "", nil
+	}
+	prev, err := readReport(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if prev.Interrupted {
+		return nil, "", fmt.Errorf("%s is an interrupted run", path)
+	}
+	return prev, path, nil
+}
+
+func readReport(path string) (*report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rep report
+	if err := json.Unmarshal(data, &rep); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &rep, nil
+}
+
+// compare checks every result in rep against the same result in base, if
+// it succeeded there, and flags the ones that now fail outright, fail more
+// requests, or moved past the thresholds. Benchmarks expect no failed
+// requests at all, so any rise in the error rate counts. When the suite
+// settings or a scenario's definition differ, the deltas are still recorded
+// but only outright failures are flagged: the numbers are not like for like.
+func compare(base *report, basePath string, rep *report, reg Regression) *comparison {
+	c := &comparison{
+		Against:     basePath,
+		GeneratedAt: base.GeneratedAt,
+		Commit:      base.Commit,
+		LoadChanged: base.Config.Load != rep.Config.Load || base.Config.Target != rep.Config.Target ||
+			base.Config.Runs != rep.Config.Runs || base.Config.Profile != rep.Config.Profile,
+	}
+	baseScenarios := scenarioDefinitions(base.Config)
+	scenarios := scenarioDefinitions(rep.Config)
+	previous := make(map[string]*result, len(base.Results))
+	for _, r := range base.Results {
+		if r.Error == "" {
+			previous[r.Server+"\x00"+r.Mode+"\x00"+r.Scenario] = r
+		}
+	}
+	for _, r := range rep.Results {
+		old, ok := previous[r.Server+"\x00"+r.Mode+"\x00"+r.Scenario]
+		if !ok {
+			continue
+		}
+		d := delta{
+			Server:          r.Server,
+			Mode:            r.Mode,
+			Scenario:        r.Scenario,
+			BaseRPS:         old.RPS.Median,
+			BaseP99Ms:       old.Latency.P99Ms,
+			BaseErrorPct:    old.errorPct(),
+			ScenarioChanged: baseScenarios[r.Scenario] != scenarios[r.Scenario],
+		}
+		if r.Error != "" {
+			d.Error = r.Error
+			d.Regression = "failed: " + r.Error
+			c.Regressions++
+			c.Deltas = append(c.Deltas, d)
+			continue
+		}
+		d.RPS = r.RPS.Median
+		d.RPSChange = percentChange(old.RPS.Median, r.RPS.Median)
+		d.P99Ms = r.Latency.P99Ms
+		d.P99Change = percentChange(old.Latency.P99Ms, r.Latency.P99Ms)
+		d.ErrorPct = r.errorPct()
+		if c.LoadChanged || d.ScenarioChanged {
+			c.Deltas = append(c.Deltas, d)
+			continue
+		}
+		var reasons []string
+		if d.ErrorPct > d.BaseErrorPct {
+			reasons = append(reasons, fmt.Sprintf("errors up from %.2f%% to %.2f%%", d.BaseErrorPct, d.ErrorPct))
+		}
+		if -d.RPSChange > reg.MaxRPSDrop {
+			reasons = append(reasons, fmt.Sprintf("RPS down %.1f%%", -d.RPSChange))
+		}
+		if d.P99Change > reg.MaxP99Rise {
+			reasons = append(reasons, fmt.Sprintf("p99 up %.1f%%", d.P99Change))
+		}
+		if len(reasons) > 0 {
+			d.Regression = strings.Join(reasons, ", ")
+			c.Regressions++
+		}
+		c.Deltas = append(c.Deltas, d)
+	}
+	return c
+}
+
+// scenarioDefinitions renders each scenario's resolved definition, keyed by
+// name, so scenarios can be compared across reports. The server allowlist
+// is left out since it does not change what a server is measured on.
+func scenarioDefinitions(cfg Config) map[string]string {
+	defs := make(map[string]string, len(cfg.Scenarios))
+	for _, sc := range cfg.Scenarios {
+		sc.Servers = nil
+		data, _ := json.Marshal(sc)
+		defs[sc.Name] = string(data)
+	}
+	return defs
+}
+
+func percentChange(before, after float64) float64 {
+	if before == 0 {
+		return 0
+	}
+	return (after - before) / before * 100
+}
+
+func (c *comparison) markdown(b *strings.Builder, reg Regression) {
+	fmt.Fprintf(b, "## Regression Check\n\n")
+	against := c.Against
+	if c.Commit != "" {
+		against += " (" + c.Commit + ")"
+	}
+	fmt.Fprintf(b, "Compared with `%s` from %s. Thresholds: RPS drop > %.0f%%, p99 rise > %.0f%%.\n\n",
+		against, c.GeneratedAt.Format(time.RFC1123), reg.MaxRPSDrop, reg.MaxP99Rise)
+	if c.LoadChanged {
+		b.WriteString("**Warning:** the baseline used a different target, load, run count, or profiling setting; deltas are not like for like, so only failures were flagged.\n\n")
+	}
+	if len(c.Deltas) == 0 {
+		b.WriteString("No results in common with the baseline.\n\n")
+		return
+	}
+	fmt.Fprintf(b, "| Server | Scenario | RPS | Change | P99 | Change | Errors | Status |\n")
+	fmt.Fprintf(b, "|--------|----------|-----|--------|-----|--------|--------|--------|\n")
+	for _, d := range c.Deltas {
+		status := "ok"
+		switch {
+		case d.Regression != "":
+			status = "**regression:** " + d.Regression
+		case d.ScenarioChanged:
+			status = "scenario changed; not compared"
+		}
+		if d.Error != "" {
+			fmt.Fprintf(b, "| %s | %s | failed | | | | | %s |\n", d.label(), d.Scenario, status)
+			continue
+		}
+		fmt.Fprintf(b, "| %s | %s | %.0f | %+.1f%% | %.2fms | %+.1f%% | %.2f%% | %s |\n",
+			d.label(), d.Scenario, d.RPS, d.RPSChange, d.P99Ms, d.P99Change, d.ErrorPct, status)
+	}
+	b.WriteString("\n")
+}
+
+// gitCommit returns the short commit the benchmarks were run from, or ""
+// outside a git checkout.
+func gitCommit(ctx context.Context, root string) string {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--short", "HEAD")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// sqliteSchema creates one row per suite and one per result so history can
+// be queried across commits with plain SQL.
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS suites (
+  stamp TEXT PRIMARY KEY,
+  generated_at TEXT NOT NULL,
+  git_commit TEXT,
+  target TEXT,
+  connections INTEGER,
+  pipeline INTEGER,
+  duration_ms INTEGER,
+  runs INTEGER
+);
+CREATE TABLE IF NOT EXISTS results (
+  stamp TEXT NOT NULL REFERENCES suites(stamp),
+  server TEXT NOT NULL,
+  mode TEXT NOT NULL,
+  scenario TEXT NOT NULL,
+  rps_median REAL,
+  rps_min REAL,
+  rps_max REAL,
+  p50_ms REAL,
+  p99_ms REAL,
+  p999_ms REAL,
+  errors INTEGER,
+  error TEXT,
+  PRIMARY KEY (stamp, server, mode, scenario)
+);
+`
+
+// appendSQLite records rep in the SQLite database at path through the
+// sqlite3 command-line tool, keeping the runner free of cgo dependencies.
+func appendSQLite(ctx context.Context, path string, rep *report) error {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return errors.New("sqlite3 not found on PATH")
+	}
+	cfg := rep.Config
+	stamp := rep.GeneratedAt.Format(stampLayout)
+	var sql bytes.Buffer
+	sql.WriteString(sqliteSchema)
+	sql.WriteString("BEGIN;\n")
+	fmt.Fprintf(&sql, "INSERT OR REPLACE INTO suites VALUES (%s, %s, %s, %s, %d, %d, %d, %d);\n",
+		sqlQuote(stamp), sqlQuote(rep.GeneratedAt.Format(time.RFC3339)), sqlQuote(rep.Commit), sqlQuote(cfg.Target),
+		cfg.Load.Connections, cfg.Load.Pipeline, cfg.Load.Duration.Milliseconds(), cfg.Runs)
+	for _, r := range rep.Results {
+		fmt.Fprintf(&sql, "INSERT OR REPLACE INTO results VALUES (%s, %s, %s, %s, %g, %g, %g, %g, %g, %g, %d, %s);\n",
+			sqlQuote(stamp), sqlQuote(r.Server), sqlQuote(r.Mode), sqlQuote(r.Scenario),
+			r.RPS.Median, r.RPS.Min, r.RPS.Max, r.Latency.P50Ms, r.Latency.P99Ms, r.Latency.P999Ms,
+			r.Errors+r.BadStatus, sqlQuote(r.Error))
+	}
+	sql.WriteString("COMMIT;\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "sqlite3", "-bail", path)
+	cmd.Stdin = &sql
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sqlite3 %s: %w\n%s", path, err, out)
+	}
+	return nil
+}
+
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testReport(rps, p99 float64) *report {
+	return &report{
+		GeneratedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Config:      defaultConfig(),
+		Results: []*result{{
+			Server:   "routed",
+			Mode:     modeAOT,
+			Scenario: "plaintext",
+			RPS:      rpsSummary{Median: rps},
+			Latency:  latencySummary{P99Ms: p99},
+		}},
+	}
+}
+
+func TestCompareDefaultThresholds(t *testing.T) {
+	reg := defaultConfig().Regression
+	base := testReport(10000, 1.0)
+	for _, tc := range []struct {
+		name       string
+		rps, p99   float64
+		regression bool
+	}{
+		{"unchanged", 10000, 1.0, false},
+		{"run-to-run noise", 9600, 1.1, false},
+		{"faster", 12000, 0.8, false},
+		{"rps drop", 8500, 1.0, true},
+		{"p99 rise", 10000, 1.3, true},
+	} {
+		c := compare(base, "base.json", testReport(tc.rps, tc.p99), reg)
+		if len(c.Deltas) != 1 {
+			t.Fatalf("%s: %d deltas, want 1", tc.name, len(c.Deltas))
+		}
+		if got := c.Regressions > 0; got != tc.regression {
+			t.Errorf("%s: regression = %v (%q), want %v", tc.name, got, c.Deltas[0].Regression, tc.regression)
+		}
+	}
+}
+
+func TestCompareLoadChanged(t *testing.T) {
+	base := testReport(10000, 1.0)
+	rep := testReport(5000, 3.0)
+	rep.Config.Load.Connections *= 2
+	c := compare(base, "base.json", rep, defaultConfig().Regression)
+	if !c.LoadChanged {
+		t.Fatal("LoadChanged = false for different connection counts")
+	}
+	if c.Regressions != 0 || c.Deltas[0].Regression != "" {
+		t.Errorf("flagged %d regressions across different loads", c.Regressions)
+	}
+	if c.Deltas[0].RPSChange != -50 {
+		t.Errorf("RPSChange = %v, want -50", c.Deltas[0].RPSChange)
+	}
+}
+
+func TestCompareLoadChangedSettings(t *testing.T) {
+	for name, change := range map[string]func(*Config){
+		"target":  func(c *Config) { c.Target = targetCompose },
+		"runs":    func(c *Config) { c.Runs++ },
+		"profile": func(c *Config) { c.Profile = !c.Profile },
+	} {
+		rep := testReport(10000, 1.0)
+		change(&rep.Config)
+		if c := compare(testReport(10000, 1.0), "base.json", rep, defaultConfig().Regression); !c.LoadChanged {
+			t.Errorf("%s: LoadChanged = false", name)
+		}
+	}
+}
+
+func TestCompareScenarioChanged(t *testing.T) {
+	base := testReport(10000, 1.0)
+	rep := testReport(5000, 3.0)
+	rep.Config.Scenarios = append([]Scenario(nil), rep.Config.Scenarios...)
+	rep.Config.Scenarios[0].Paths = []string{"/json"}
+	c := compare(base, "base.json", rep, defaultConfig().Regression)
+	if c.LoadChanged || !c.Deltas[0].ScenarioChanged {
+		t.Fatalf("LoadChanged = %v, ScenarioChanged = %v; want only the scenario flagged", c.LoadChanged, c.Deltas[0].ScenarioChanged)
+	}
+	if c.Regressions != 0 {
+		t.Errorf("flagged %d regressions for a changed scenario", c.Regressions)
+	}
+
+	// Restricting a scenario to fewer servers does not change it.
+	rep.Config.Scenarios[0] = base.Config.Scenarios[0]
+	rep.Config.Scenarios[0].Servers = []string{"routed"}
+	if c := compare(base, "base.json", rep, defaultConfig().Regression); c.Deltas[0].ScenarioChanged {
+		t.Error("server list counted as a scenario change")
+	}
+}
+
+func TestCompareFailures(t *testing.T) {
+	base := testReport(10000, 1.0)
+	rep := testReport(0, 0)
+	rep.Results[0].Error = "server exited"
+	c := compare(base, "base.json", rep, defaultConfig().Regression)
+	if c.Regressions != 1 || len(c.Deltas) != 1 || c.Deltas[0].Error != "server exited" {
+		t.Fatalf("new failure: %d regressions, deltas %+v", c.Regressions, c.Deltas)
+	}
+
+	// Failures are flagged even when the numbers are not like for like.
+	rep.Config.Load.Connections *= 2
+	if c := compare(base, "base.json", rep, defaultConfig().Regression); c.Regressions != 1 {
+		t.Errorf("new failure under a changed load: %d regressions, want 1", c.Regressions)
+	}
+
+	// A result that failed in the baseline has nothing to compare with.
+	base.Results[0].Error = "build failed"
+	if c := compare(base, "base.json", testReport(10000, 1.0), defaultConfig().Regression); len(c.Deltas) != 0 {
+		t.Errorf("compared against a failed baseline: %+v", c.Deltas)
+	}
+}
+
+func TestCompareErrorRate(t *testing.T) {
+	withErrors := func(errors, badStatus uint64) *report {
+		rep := testReport(10000, 1.0)
+		r := rep.Results[0]
+		r.Runs = []runStats{{Requests: 1000 - errors, Errors: errors, BadStatus: badStatus}}
+		r.Errors, r.BadStatus = errors, badStatus
+		return rep
+	}
+	for _, tc := range []struct {
+		name       string
+		base, rep  *report
+		wantPct    float64
+		regression bool
+	}{
+		{"clean", withErrors(0, 0), withErrors(0, 0), 0, false},
+		{"socket errors", withErrors(0, 0), withErrors(10, 0), 1, true},
+		{"bad status", withErrors(0, 0), withErrors(0, 1000), 100, true},
+		{"fewer errors", withErrors(10, 0), withErrors(0, 5), 0.5, false},
+	} {
+		c := compare(tc.base, "base.json", tc.rep, defaultConfig().Regression)
+		d := c.Deltas[0]
+		if d.ErrorPct != tc.wantPct {
+			t.Errorf("%s: ErrorPct = %v, want %v", tc.name, d.ErrorPct, tc.wantPct)
+		}
+		if got := c.Regressions > 0; got != tc.regression {
+			t.Errorf("%s: regression = %v (%q), want %v", tc.name, got, d.Regression, tc.regression)
+		}
+	}
+}
+
+func TestPreviousReportSkipsInterrupted(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, interrupted bool) {
+		rep := testReport(1, 1)
+		rep.Interrupted = interrupted
+		data, err := json.Marshal(rep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if prev, _, err := previousReport(dir, Regression{}); err != nil || prev != nil {
+		t.Fatalf("empty dir = %v, %v; want nothing", prev, err)
+	}
+	write("runner-20260101-000000.json", false)
+	write("runner-20260102-000000.json", false)
+	write("runner-20260103-000000.json", true)
+
+	_, path, err := previousReport(dir, Regression{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "runner-20260102-000000.json"); path != want {
+		t.Errorf("baseline = %s, want %s", path, want)
+	}
+	if _, _, err := previousReport(dir, Regression{Against: filepath.Join(dir, "runner-20260103-000000.json")}); err == nil {
+		t.Error("explicit interrupted baseline accepted")
+	}
+	if prev, _, err := previousReport(dir, Regression{Against: "none"}); err != nil || prev != nil {
+		t.Errorf("none = %v, %v; want nothing", prev, err)
+	}
+}
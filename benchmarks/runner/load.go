@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ioTimeout bounds a single pipelined batch so a stalled server cannot hang
+// a worker. Batches are also cut off at the end of the run.
+const ioTimeout = 10 * time.Second
+
+// loadResult is the merged outcome of one load run.
+type loadResult struct {
+	Requests   uint64
+	Errors     uint64
+	BadStatus  uint64
+	BytesRead  uint64
+	Elapsed    time.Duration
+	histogram  *histogram
+	firstError error
+}
+
+func (r *loadResult) rps() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// rawRequests renders each scenario path into wire-format HTTP/1.1 so the
+// hot loop only copies bytes.
+func rawRequests(addr string, sc Scenario) [][]byte {
+	out := make([][]byte, len(sc.Paths))
+	body := sc.body()
+	for i, path := range sc.Paths {
+		var b bytes.Buffer
+		fmt.Fprintf(&b, "%s %s HTTP/1.1\r\nHost: %s\r\nUser-Agent: routed-bench\r\n", sc.Method, path, addr)
+		for k, v := range sc.Headers {
+			fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+		}
+		if body != "" || sc.Method == http.MethodPost || sc.Method == http.MethodPut || sc.Method == http.MethodPatch {
+			fmt.Fprintf(&b, "Content-Length: %d\r\n", len(body))
+		}
+		b.WriteString("\r\n")
+		b.WriteString(body)
+		out[i] = b.Bytes()
+	}
+	return out
+}
+
+// runLoad drives addr with the scenario using `connections` persistent
+// connections, each keeping `pipeline` requests in flight, for `duration`.
+func runLoad(ctx context.Context, addr string, sc Scenario, connections, pipeline int, duration time.Duration) *loadResult {
+	requests := rawRequests(addr, sc)
+	end := time.Now().Add(duration)
+	ctx, cancel := context.WithDeadline(ctx, end)
+	defer cancel()
+
+	workers := make([]*worker, connections)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range workers {
+		w := &worker{
+			addr:     addr,
+			scenario: sc,
+			requests: requests,
+			next:     i % len(requests),
+			pipeline: pipeline,
+			end:      end,
+			hist:     newHistogram(),
+		}
+		workers[i] = w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	result := &loadResult{Elapsed: time.Since(start), histogram: newHistogram()}
+	for _, w := range workers {
+		result.Requests += w.completed
+		result.Errors += w.errors
+		result.BadStatus += w.badStatus
+		result.BytesRead += w.bytesRead
+		result.histogram.merge(w.hist)
+		if result.firstError == nil {
+			result.firstError = w.firstError
+		}
+	}
+	return result
+}
+
+type worker struct {
+	addr     string
+	scenario Scenario
+	requests [][]byte
+	next     int
+	pipeline int
+	end      time.Time
+	hist     *histogram
+
+	conn net.Conn
+	br   *bufio.Reader
+	buf  bytes.Buffer
+
+	completed  uint64
+	errors     uint64
+	badStatus  uint64
+	bytesRead  uint64
+	firstError error
+}
+
+func (w *worker) run(ctx context.Context) {
+	defer w.close()
+	probe := &http.Request{Method: w.scenario.Method}
+	for ctx.Err() == nil {
+		if w.conn == nil {
+			if err := w.dial(ctx); err != nil {
+				if time.Now().Before(w.end) {
+					w.fail(err, 1)
+					time.Sleep(10 * time.Millisecond)
+				}
+				continue
+			}
+		}
+
+		w.buf.Reset()
+		for i := 0; i < w.pipeline; i++ {
+			w.buf.Write(w.requests[w.next])
+			w.next = (w.next + 1) % len(w.requests)
+		}
+		deadline := time.Now().Add(ioTimeout)
+		if w.end.Before(deadline) {
+			deadline = w.end
+		}
+		_ = w.conn.SetDeadline(deadline)
+		sent := time.Now()
+		if _, err := w.conn.Write(w.buf.Bytes()); err != nil {
+			w.resetOnError(err, w.pipeline)
+			continue
+		}
+		for i := 0; i < w.pipeline; i++ {
+			resp, err := http.ReadResponse(w.br, probe)
+			if err != nil {
+				w.resetOnError(err, w.pipeline-i)
+				break
+			}
+			n, err := io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				w.resetOnError(err, w.pipeline-i)
+				break
+			}
+			w.hist.record(time.Since(sent))
+			w.completed++
+			w.bytesRead += uint64(n)
+			if resp.StatusCode != w.scenario.ExpectStatus {
+				w.badStatus++
+				if w.firstError == nil {
+					w.firstError = fmt.Errorf("unexpected status %d (want %d)", resp.StatusCode, w.scenario.ExpectStatus)
+				}
+			}
+			if resp.Close {
+				// Requests pipelined behind this response will never be
+				// answered on this connection.
+				if unanswered := w.pipeline - 1 - i; unanswered > 0 {
+					w.resetOnError(fmt.Errorf("connection closed with %d pipelined requests unanswered", unanswered), unanswered)
+				} else {
+					w.close()
+				}
+				break
+			}
+		}
+	}
+}
+
+func (w *worker) dial(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", w.addr)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	w.br = bufio.NewReaderSize(conn, 16<<10)
+	return nil
+}
+
+// resetOnError drops the connection and counts each of the unanswered
+// in-flight requests as an error. Errors caused by the end of the run cutting
+// off in-flight batches are not counted.
+func (w *worker) resetOnError(err error, unanswered int) {
+	w.close()
+	if time.Now().Before(w.end) {
+		w.fail(err, unanswered)
+	}
+}
+
+func (w *worker) fail(err error, requests int) {
+	w.errors += uint64(requests)
+	if w.firstError == nil {
+		w.firstError = err
+	}
+}
+
+func (w *worker) close() {
+	if w.conn != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+		w.br = nil
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRawRequests(t *testing.T) {
+	sc := Scenario{
+		Method:   http.MethodPost,
+		Paths:    []string{"/upload", "/upload?n=2"},
+		Headers:  map[string]string{"Content-Type": "application/octet-stream"},
+		BodySize: 1024,
+	}
+	reqs := rawRequests("127.0.0.1:8004", sc)
+	if len(reqs) != len(sc.Paths) {
+		t.Fatalf("got %d requests, want %d", len(reqs), len(sc.Paths))
+	}
+	for i, raw := range reqs {
+		req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(raw)))
+		if err != nil {
+			t.Fatalf("request %d does not parse: %v\n%s", i, err, raw)
+		}
+		if req.Method != sc.Method || req.RequestURI != sc.Paths[i] || req.Host != "127.0.0.1:8004" {
+			t.Errorf("request %d = %s %s (host %s)", i, req.Method, req.RequestURI, req.Host)
+		}
+		if got := req.Header.Get("Content-Type"); got != "application/octet-stream" {
+			t.Errorf("request %d Content-Type = %q", i, got)
+		}
+		body, _ := io.ReadAll(req.Body)
+		if len(body) != sc.BodySize || req.ContentLength != int64(sc.BodySize) {
+			t.Errorf("request %d body = %d bytes, Content-Length %d, want %d", i, len(body), req.ContentLength, sc.BodySize)
+		}
+	}
+}
+
+func TestRawRequestsEmptyPost(t *testing.T) {
+	raw := rawRequests("h", Scenario{Method: http.MethodPost, Paths: []string{"/"}})[0]
+	if !strings.Contains(string(raw), "Content-Length: 0\r\n") {
+		t.Errorf("empty POST has no Content-Length:\n%s", raw)
+	}
+	raw = rawRequests("h", Scenario{Method: http.MethodGet, Paths: []string{"/"}})[0]
+	if strings.Contains(string(raw), "Content-Length") {
+		t.Errorf("GET carries a Content-Length:\n%s", raw)
+	}
+}
+
+func TestRunLoad(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	sc := Scenario{Method: http.MethodGet, Paths: []string{"/", "/missing"}, ExpectStatus: http.StatusOK}
+	res := runLoad(context.Background(), addr, sc, 2, 4, 200*time.Millisecond)
+	if res.Requests == 0 {
+		t.Fatalf("no requests completed (first error: %v)", res.firstError)
+	}
+	if res.Errors != 0 {
+		t.Errorf("errors = %d (first: %v)", res.Errors, res.firstError)
+	}
+	// Every other path is a 404.
+	if res.BadStatus == 0 || res.BadStatus > res.Requests {
+		t.Errorf("bad status = %d of %d requests", res.BadStatus, res.Requests)
+	}
+	if res.histogram.total != res.Requests {
+		t.Errorf("histogram holds %d samples for %d requests", res.histogram.total, res.Requests)
+	}
+}
+
+func TestRunLoadCountsUnansweredPipelined(t *testing.T) {
+	// The server answers one request per connection and drops the rest of
+	// each pipelined batch.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	sc := Scenario{Method: http.MethodGet, Paths: []string{"/"}, ExpectStatus: http.StatusOK}
+	res := runLoad(context.Background(), addr, sc, 1, 4, 200*time.Millisecond)
+	if res.Requests == 0 {
+		t.Fatalf("no requests completed (first error: %v)", res.firstError)
+	}
+	// Batches cut off by the end of the run are not counted.
+	if res.Errors < 3*(res.Requests-1) {
+		t.Errorf("errors = %d for %d answered batches of 4, want at least %d", res.Errors, res.Requests, 3*(res.Requests-1))
+	}
+}
@@ -0,0 +1,389 @@
+// Command runner boots each benchmark server, drives it with configurable
+// load scenarios, and writes JSON and Markdown comparison reports.
+//
+// Usage:
+//
+//	cd benchmarks/runner
+//	go run .                                  # every server, AOT + JIT
+//	go run . -servers routed,go -mode aot     # subset
+//	go run . -c 256 -pipeline 16 -d 30s       # heavier load
+//	go run . -config my-scenarios.json        # custom scenarios
+//	go run . -target compose -mode aot        # servers from docker-compose.yml
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+func main() {
+	log.SetFlags(log.Ltime)
+	if err := run(); err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+}
+
+func run() error {
+	var (
+		configPath  = flag.String("config", "", "JSON config file layered over the defaults")
+		root        = flag.String("root", "", "benchmarks directory (default: auto-detect)")
+		serverList  = flag.String("servers", "", "comma-separated servers to run (default: all)")
+		scenarioSel = flag.String("scenarios", "", "comma-separated scenario names to run (default: all)")
+		mode        = flag.String("mode", "", "aot, jit, or both (default: both)")
+		target      = flag.String("target", "", "local (build and start servers) or compose (use running compose services)")
+		runs        = flag.Int("runs", 0, "measured runs per scenario")
+		connections = flag.Int("c", 0, "concurrent connections")
+		pipeline    = flag.Int("pipeline", 0, "pipelined requests per connection")
+		duration    = flag.Duration("d", 0, "duration of each measured run")
+		warmup      = flag.Duration("warmup", -1, "warmup duration for AOT and native servers")
+		warmupJIT   = flag.Duration("warmup-jit", -1, "warmup duration for JIT servers")
+		outDir      = flag.String("out", "", "directory for reports (default: <root>/results)")
+		skipBuild   = flag.Bool("skip-build", false, "reuse existing server builds")
+		noProfile   = flag.Bool("no-profile", false, "skip memory, GC, and allocation profiling")
+		against     = flag.String("compare", "", "report to check for regressions (default: newest in -out; none to skip)")
+		maxRPSDrop  = flag.Float64("max-rps-drop", -1, "tolerated median RPS drop in percent")
+		maxP99Rise  = flag.Float64("max-p99-rise", -1, "tolerated p99 latency rise in percent")
+		failOnReg   = flag.Bool("fail-on-regression", false, "exit non-zero when a result regresses")
+		sqlitePath  = flag.String("sqlite", "", "also append results to this SQLite database (needs sqlite3)")
+	)
+	flag.Parse()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *serverList != "" {
+		cfg.Servers = splitList(*serverList)
+	}
+	if *scenarioSel != "" {
+		cfg.Scenarios = selectScenarios(cfg.Scenarios, splitList(*scenarioSel))
+		if len(cfg.Scenarios) == 0 {
+			return fmt.Errorf("no scenarios match %q", *scenarioSel)
+		}
+	}
+	switch *mode {
+	case "":
+	case "both":
+		cfg.Modes = []string{modeAOT, modeJIT}
+	default:
+		cfg.Modes = []string{*mode}
+	}
+	if *target != "" {
+		cfg.Target = *target
+	}
+	if *runs > 0 {
+		cfg.Runs = *runs
+	}
+	if *connections > 0 {
+		cfg.Load.Connections = *connections
+	}
+	if *pipeline > 0 {
+		cfg.Load.Pipeline = *pipeline
+	}
+	if *duration > 0 {
+		cfg.Load.Duration.Duration = *duration
+	}
+	if *warmup >= 0 {
+		cfg.Load.Warmup.Duration = *warmup
+	}
+	if *warmupJIT >= 0 {
+		cfg.Load.WarmupJIT.Duration = *warmupJIT
+	}
+	if *noProfile {
+		cfg.Profile = false
+	}
+	if *against != "" {
+		cfg.Regression.Against = *against
+	}
+	if *maxRPSDrop >= 0 {
+		cfg.Regression.MaxRPSDrop = *maxRPSDrop
+	}
+	if *maxP99Rise >= 0 {
+		cfg.Regression.MaxP99Rise = *maxP99Rise
+	}
+	if *failOnReg {
+		cfg.Regression.Fail = true
+	}
+	if *sqlitePath != "" {
+		cfg.SQLite = *sqlitePath
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	benchRoot := *root
+	if benchRoot == "" {
+		if benchRoot, err = findRoot(); err != nil {
+			return err
+		}
+	}
+	if *outDir != "" {
+		cfg.OutDir = *outDir
+	}
+	if cfg.OutDir == "" {
+		cfg.OutDir = filepath.Join(benchRoot, "results")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Find the baseline before this run's report lands in the same directory.
+	prev, prevPath, err := previousReport(cfg.OutDir, cfg.Regression)
+	if err != nil {
+		return fmt.Errorf("load baseline report: %w", err)
+	}
+
+	rep := &report{GeneratedAt: time.Now(), Commit: gitCommit(ctx, benchRoot), Config: cfg}
+	s := &suite{root: benchRoot, cfg: cfg, skipBuild: *skipBuild, stamp: rep.GeneratedAt.Format(stampLayout)}
+	if cfg.Target == targetCompose {
+		if s.compose = newComposeStack(benchRoot); s.compose != nil {
+			// Stop anything left running so each server is measured alone.
+			s.compose.stop(composeServices(cfg)...)
+		}
+	}
+	log.Printf("=== Benchmark: %d connections, pipeline %d, %s x %d runs ===",
+		cfg.Load.Connections, cfg.Load.Pipeline, cfg.Load.Duration, cfg.Runs)
+	for _, name := range cfg.Servers {
+		spec, _ := lookupServer(name)
+		for _, m := range spec.modes(cfg.Modes) {
+			if ctx.Err() != nil {
+				break
+			}
+			rep.Results = append(rep.Results, s.benchmarkServer(ctx, spec, m)...)
+		}
+	}
+
+	rep.Interrupted = ctx.Err() != nil
+	if prev != nil && !rep.Interrupted {
+		rep.Comparison = compare(prev, prevPath, rep, cfg.Regression)
+		if rep.Comparison.LoadChanged {
+			log.Printf("WARNING: %s used a different target, load, run count, or profiling setting; only failures checked", prevPath)
+		}
+		for _, d := range rep.Comparison.Deltas {
+			if d.Regression != "" {
+				log.Printf("REGRESSION: %s %s: %s", d.label(), d.Scenario, d.Regression)
+			}
+		}
+	}
+
+	jsonPath, mdPath, err := rep.write(cfg.OutDir)
+	if err != nil {
+		return err
+	}
+	log.Printf("Report: %s", mdPath)
+	log.Printf("Data:   %s", jsonPath)
+	// Partial suites stay out of the history database.
+	if cfg.SQLite != "" && !rep.Interrupted {
+		if err := appendSQLite(ctx, cfg.SQLite, rep); err != nil {
+			return err
+		}
+		log.Printf("SQLite: %s", cfg.SQLite)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if c := rep.Comparison; c != nil && c.Regressions > 0 && cfg.Regression.Fail {
+		return fmt.Errorf("%d regression(s) against %s", c.Regressions, c.Against)
+	}
+	return nil
+}
+
+// suite carries the settings shared by every server in one invocation.
+type suite struct {
+	root      string
+	cfg       Config
+	skipBuild bool
+	stamp     string
+	compose   *composeStack
+}
+
+// composeServices lists the compose services for every server and mode in
+// the suite.
+func composeServices(cfg Config) []string {
+	var services []string
+	for _, name := range cfg.Servers {
+		spec, _ := lookupServer(name)
+		for _, m := range spec.modes(cfg.Modes) {
+			services = append(services, spec.service(m))
+		}
+	}
+	return services
+}
+
+// benchmarkServer builds, starts, warms up, and measures one server in one
+// mode. Failures are recorded on the results instead of aborting the suite.
+func (s *suite) benchmarkServer(ctx context.Context, spec serverSpec, mode string) []*result {
+	cfg := s.cfg
+	label := spec.name
+	if mode != "" {
+		label += " (" + mode + ")"
+	}
+	var results []*result
+	for _, sc := range cfg.Scenarios {
+		if sc.supports(spec.name) {
+			results = append(results, &result{Server: spec.name, Mode: mode, Scenario: sc.Name})
+		}
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	failAll := func(err error) []*result {
+		log.Printf("ERROR: %s: %v", label, err)
+		for _, r := range results {
+			r.Error = err.Error()
+		}
+		return results
+	}
+
+	readyTimeout := 15 * time.Second
+	if mode == modeJIT {
+		readyTimeout = 60 * time.Second
+	}
+	host := cfg.Host
+	if cfg.Target == targetCompose {
+		host = spec.service(mode)
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(spec.port))
+	base := "http://" + addr
+
+	pgid := 0
+	var vm *vmService
+	if cfg.Target == targetCompose {
+		// Compose services are built by `docker compose build` and started
+		// here one at a time; peak RSS comes from /debug/stats since the
+		// processes are not ours.
+		log.Printf("=== %s at %s ===", label, addr)
+		if s.compose != nil {
+			service := spec.service(mode)
+			if err := s.compose.up(ctx, service); err != nil {
+				return failAll(err)
+			}
+			defer s.compose.stop(service)
+		}
+		if err := waitReady(ctx, base+"/", readyTimeout, nil); err != nil {
+			return failAll(err)
+		}
+	} else {
+		log.Printf("=== %s on port %d ===", label, spec.port)
+		if !s.skipBuild {
+			log.Printf("Building...")
+			if err := spec.build(ctx, s.root, mode); err != nil {
+				return failAll(err)
+			}
+		}
+		proc, err := spec.start(s.root, host, mode, cfg.Profile)
+		if err != nil {
+			return failAll(err)
+		}
+		defer proc.stop()
+		if err := waitReady(ctx, base+"/", readyTimeout, proc.done); err != nil {
+			return failAll(err)
+		}
+		pgid = proc.cmd.Process.Pid
+		log.Printf("Server ready (PID: %d)", pgid)
+	}
+	if cfg.Profile && spec.kind == kindDart && mode == modeJIT {
+		// Local servers bind the VM service to loopback; compose services
+		// expose it on the bench network under their service name.
+		vmHost := "127.0.0.1"
+		if cfg.Target == targetCompose {
+			vmHost = host
+		}
+		vmAddr := net.JoinHostPort(vmHost, strconv.Itoa(spec.vmServicePort()))
+		var err error
+		if vm, err = dialVMService(ctx, vmAddr); err != nil {
+			log.Printf("WARNING: %v; Dart allocation and GC figures unavailable", err)
+		} else {
+			defer vm.close()
+		}
+	}
+
+	warmup := cfg.Load.Warmup.Duration
+	if mode == modeJIT {
+		warmup = cfg.Load.WarmupJIT.Duration
+	}
+	i := 0
+	for _, sc := range cfg.Scenarios {
+		if !sc.supports(spec.name) {
+			continue
+		}
+		r := results[i]
+		i++
+		if warmup > 0 {
+			log.Printf("%s: warmup (%s)...", sc.Name, warmup)
+			runLoad(ctx, addr, sc, cfg.Load.Connections, cfg.Load.Pipeline, warmup)
+		}
+		for run := 1; run <= cfg.Runs && ctx.Err() == nil; run++ {
+			var prof *profiler
+			if cfg.Profile {
+				prof = startProfiler(base, pgid, vm)
+			}
+			lr := runLoad(ctx, addr, sc, cfg.Load.Connections, cfg.Load.Pipeline, cfg.Load.Duration.Duration)
+			stats := newRunStats(lr)
+			if prof != nil {
+				stats.Memory = prof.finish(lr.Requests)
+			}
+			r.Runs = append(r.Runs, stats)
+			log.Printf("%s: run %d/%d: %.0f RPS, p99 %.2fms, %d errors%s",
+				sc.Name, run, cfg.Runs, stats.RPS, stats.P99Ms, stats.Errors+stats.BadStatus, stats.Memory.summary())
+			if lr.firstError != nil {
+				log.Printf("%s: first error: %v", sc.Name, lr.firstError)
+			}
+		}
+		r.summarize()
+		if len(r.Runs) == 0 && ctx.Err() != nil {
+			r.Error = "interrupted"
+		}
+		if cfg.Profile && ctx.Err() == nil {
+			name := spec.name
+			if mode != "" {
+				name += "-" + mode
+			}
+			path := filepath.Join(cfg.OutDir, "profiles", s.stamp, name+"-"+sc.Name+".heap.pb.gz")
+			if saved, err := captureHeapProfile(base, path); err != nil {
+				log.Printf("%s: heap profile: %v", sc.Name, err)
+			} else if saved != "" {
+				r.Memory.HeapProfilePath = saved
+			}
+		}
+	}
+	return results
+}
+
+func selectScenarios(all []Scenario, names []string) []Scenario {
+	var out []Scenario
+	for _, sc := range all {
+		for _, name := range names {
+			if sc.Name == name {
+				out = append(out, sc)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// findRoot locates the benchmarks directory from the working directory so
+// the runner works from benchmarks/, benchmarks/runner/, or the repo root.
+func findRoot() (string, error) {
+	for _, candidate := range []string{".", "..", "benchmarks"} {
+		abs, err := filepath.Abs(candidate)
+		if err != nil {
+			return "", err
+		}
+		if info, err := os.Stat(filepath.Join(abs, "servers")); err == nil && info.IsDir() {
+			return abs, nil
+		}
+	}
+	return "", errors.New("cannot find benchmarks/servers; pass -root")
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+func setProcessGroup(*exec.Cmd) {}
+
+func terminateProcessGroup(cmd *exec.Cmd, _ bool) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
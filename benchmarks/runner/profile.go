@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// debugStats is the payload servers return from /debug/stats when started
+// with BENCH_PROFILE=1. Runtimes omit the counters they cannot report.
+type debugStats struct {
+	Runtime         string   `json:"runtime"`
+	RSSBytes        *uint64  `json:"rss_bytes"`
+	MaxRSSBytes     *uint64  `json:"max_rss_bytes"`
+	TotalAllocBytes *uint64  `json:"total_alloc_bytes"`
+	Mallocs         *uint64  `json:"mallocs"`
+	NumGC           *uint64  `json:"num_gc"`
+	GCPauseNs       []uint64 `json:"gc_pause_ns"`
+}
+
+// memoryStats is what a measured run records about server memory. Fields
+// are nil when the server cannot report them.
+type memoryStats struct {
+	PeakRSSMB       *float64 `json:"peak_rss_mb,omitempty"`
+	AllocsPerReq    *float64 `json:"allocs_per_req,omitempty"`
+	BytesPerReq     *float64 `json:"bytes_per_req,omitempty"`
+	GCCount         *uint64  `json:"gc_count,omitempty"`
+	GCPauseP50Ms    *float64 `json:"gc_pause_p50_ms,omitempty"`
+	GCPauseP99Ms    *float64 `json:"gc_pause_p99_ms,omitempty"`
+	GCPauseMaxMs    *float64 `json:"gc_pause_max_ms,omitempty"`
+	HeapProfilePath string   `json:"heap_profile,omitempty"`
+	// PeakRSSLifetime marks a peak that could not be reset before the run,
+	// so it is the server's high-water mark since it started.
+	PeakRSSLifetime bool `json:"peak_rss_lifetime,omitempty"`
+}
+
+// summary renders the figures that are present for the per-run log line.
+func (m memoryStats) summary() string {
+	var b strings.Builder
+	if m.PeakRSSMB != nil {
+		fmt.Fprintf(&b, ", peak RSS %.1fMB", *m.PeakRSSMB)
+	}
+	if m.AllocsPerReq != nil {
+		fmt.Fprintf(&b, ", %.1f allocs/req", *m.AllocsPerReq)
+	}
+	if m.GCPauseP99Ms != nil {
+		fmt.Fprintf(&b, ", GC p99 %.3fms", *m.GCPauseP99Ms)
+	}
+	return b.String()
+}
+
+var statsClient = &http.Client{Timeout: 5 * time.Second}
+
+func fetchStats(base string) (*debugStats, error) {
+	resp, err := statsClient.Get(base + "/debug/stats")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("/debug/stats returned %d", resp.StatusCode)
+	}
+	var stats debugStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// profiler measures a server's memory across one measured run. It only
+// talks to the server before and after the run: /debug/stats stops the
+// world in some runtimes, so polling it would skew the latencies measured.
+type profiler struct {
+	base      string
+	pgid      int
+	vm        *vmService
+	peakReset bool
+	before    *debugStats
+}
+
+// startProfiler resets the peak RSS of the server's process group and
+// snapshots its counters. pgid is 0 when the server is not a local process;
+// vm is the Dart VM service of a JIT server, or nil.
+func startProfiler(base string, pgid int, vm *vmService) *profiler {
+	p := &profiler{base: base, pgid: pgid, vm: vm}
+	p.peakReset = resetPeakRSS(pgid)
+	p.before, _ = fetchStats(base)
+	if vm != nil {
+		if err := vm.begin(); err != nil {
+			log.Printf("vm service: %v", err)
+			p.vm = nil
+		}
+	}
+	return p
+}
+
+// finish derives peak memory and per-request figures for the requests
+// completed during the run.
+func (p *profiler) finish(requests uint64) memoryStats {
+	var m memoryStats
+	after, _ := fetchStats(p.base)
+	// The kernel's high-water mark for the process group catches spikes
+	// that sampling would miss. Without procfs, fall back to the peak the
+	// server reports for itself, which covers its whole lifetime.
+	if peak, ok := processGroupPeakRSS(p.pgid); ok {
+		m.PeakRSSMB = ptr(float64(peak) / (1 << 20))
+		m.PeakRSSLifetime = !p.peakReset
+	} else if after != nil && after.MaxRSSBytes != nil {
+		m.PeakRSSMB = ptr(float64(*after.MaxRSSBytes) / (1 << 20))
+		m.PeakRSSLifetime = true
+	}
+
+	if p.vm != nil {
+		if vs, err := p.vm.collect(); err != nil {
+			log.Printf("vm service: %v", err)
+		} else {
+			m.fromVM(vs, requests)
+		}
+	}
+	if after == nil || p.before == nil {
+		return m
+	}
+	before := p.before
+	if requests > 0 {
+		if before.Mallocs != nil && after.Mallocs != nil {
+			m.AllocsPerReq = ptr(float64(*after.Mallocs-*before.Mallocs) / float64(requests))
+		}
+		if before.TotalAllocBytes != nil && after.TotalAllocBytes != nil {
+			m.BytesPerReq = ptr(float64(*after.TotalAllocBytes-*before.TotalAllocBytes) / float64(requests))
+		}
+	}
+	if before.NumGC != nil && after.NumGC != nil {
+		count := *after.NumGC - *before.NumGC
+		// gc_pause_ns holds the most recent pauses, oldest first; the run's
+		// collections are the tail of that list.
+		pauses := after.GCPauseNs
+		if uint64(len(pauses)) > count {
+			pauses = pauses[uint64(len(pauses))-count:]
+		}
+		m.setGCPauses(count, pauses)
+	}
+	return m
+}
+
+// fromVM fills in the figures the Dart VM service reported for the run.
+// Allocation counts are omitted when the VM did not accumulate them.
+func (m *memoryStats) fromVM(vs *vmRunStats, requests uint64) {
+	if requests > 0 && vs.Allocs > 0 {
+		m.AllocsPerReq = ptr(float64(vs.Allocs) / float64(requests))
+		m.BytesPerReq = ptr(float64(vs.AllocBytes) / float64(requests))
+	}
+	m.setGCPauses(uint64(len(vs.GCPauseNs)), vs.GCPauseNs)
+}
+
+func (m *memoryStats) setGCPauses(count uint64, pauses []uint64) {
+	m.GCCount = &count
+	if len(pauses) == 0 {
+		return
+	}
+	sorted := append([]uint64(nil), pauses...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	m.GCPauseP50Ms = ptr(nsToMs(pauseAt(sorted, 50)))
+	m.GCPauseP99Ms = ptr(nsToMs(pauseAt(sorted, 99)))
+	m.GCPauseMaxMs = ptr(nsToMs(sorted[len(sorted)-1]))
+}
+
+// captureHeapProfile saves the server's pprof heap profile when it exposes
+// one. It returns "" for servers without pprof.
+func captureHeapProfile(base, path string) (string, error) {
+	resp, err := statsClient.Get(base + "/debug/pprof/heap")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+func pauseAt(sorted []uint64, p float64) uint64 {
+	i := int(p / 100 * float64(len(sorted)))
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+func nsToMs(ns uint64) float64 {
+	return float64(ns) / float64(time.Millisecond)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
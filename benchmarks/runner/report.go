@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// stampLayout names report and profile files after the suite start time.
+const stampLayout = "20060102-150405"
+
+// runStats is the outcome of a single measured run. Latencies are in ms.
+type runStats struct {
+	RPS       float64     `json:"rps"`
+	Requests  uint64      `json:"requests"`
+	Errors    uint64      `json:"errors"`
+	BadStatus uint64      `json:"bad_status"`
+	MeanMs    float64     `json:"mean_ms"`
+	P50Ms     float64     `json:"p50_ms"`
+	P90Ms     float64     `json:"p90_ms"`
+	P99Ms     float64     `json:"p99_ms"`
+	P999Ms    float64     `json:"p999_ms"`
+	MaxMs     float64     `json:"max_ms"`
+	Memory    memoryStats `json:"memory"`
+}
+
+func newRunStats(r *loadResult) runStats {
+	h := r.histogram
+	return runStats{
+		RPS:       r.rps(),
+		Requests:  r.Requests,
+		Errors:    r.Errors,
+		BadStatus: r.BadStatus,
+		MeanMs:    ms(h.mean()),
+		P50Ms:     ms(h.percentile(50)),
+		P90Ms:     ms(h.percentile(90)),
+		P99Ms:     ms(h.percentile(99)),
+		P999Ms:    ms(h.percentile(99.9)),
+		MaxMs:     ms(time.Duration(h.max) * time.Microsecond),
+	}
+}
+
+type rpsSummary struct {
+	Median float64 `json:"median"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+}
+
+// latencySummary holds the median of each latency statistic across runs.
+type latencySummary struct {
+	MeanMs float64 `json:"mean_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	P999Ms float64 `json:"p999_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+// result aggregates every run of one scenario against one server/mode.
+type result struct {
+	Server    string         `json:"server"`
+	Mode      string         `json:"mode,omitempty"`
+	Scenario  string         `json:"scenario"`
+	Runs      []runStats     `json:"runs,omitempty"`
+	RPS       rpsSummary     `json:"rps"`
+	Latency   latencySummary `json:"latency"`
+	Memory    memoryStats    `json:"memory"`
+	Errors    uint64         `json:"errors"`
+	BadStatus uint64         `json:"bad_status"`
+	Error     string         `json:"error,omitempty"`
+}
+
+func (r *result) summarize() {
+	if len(r.Runs) == 0 {
+		return
+	}
+	pick := func(f func(runStats) float64) []float64 {
+		out := make([]float64, len(r.Runs))
+		for i, run := range r.Runs {
+			out[i] = f(run)
+		}
+		sort.Float64s(out)
+		return out
+	}
+	rps := pick(func(s runStats) float64 { return s.RPS })
+	r.RPS = rpsSummary{Median: median(rps), Min: rps[0], Max: rps[len(rps)-1]}
+	r.Latency = latencySummary{
+		MeanMs: median(pick(func(s runStats) float64 { return s.MeanMs })),
+		P50Ms:  median(pick(func(s runStats) float64 { return s.P50Ms })),
+		P90Ms:  median(pick(func(s runStats) float64 { return s.P90Ms })),
+		P99Ms:  median(pick(func(s runStats) float64 { return s.P99Ms })),
+		P999Ms: median(pick(func(s runStats) float64 { return s.P999Ms })),
+		MaxMs:  median(pick(func(s runStats) float64 { return s.MaxMs })),
+	}
+	r.Memory = summarizeMemory(r.Runs)
+	r.Errors, r.BadStatus = 0, 0
+	for _, run := range r.Runs {
+		r.Errors += run.Errors
+		r.BadStatus += run.BadStatus
+	}
+}
+
+// summarizeMemory takes the median of each memory figure over the runs
+// that reported it.
+func summarizeMemory(runs []runStats) memoryStats {
+	medianOf := func(f func(memoryStats) *float64) *float64 {
+		var values []float64
+		for _, run := range runs {
+			if v := f(run.Memory); v != nil {
+				values = append(values, *v)
+			}
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		sort.Float64s(values)
+		return ptr(median(values))
+	}
+	m := memoryStats{
+		PeakRSSMB:    medianOf(func(m memoryStats) *float64 { return m.PeakRSSMB }),
+		AllocsPerReq: medianOf(func(m memoryStats) *float64 { return m.AllocsPerReq }),
+		BytesPerReq:  medianOf(func(m memoryStats) *float64 { return m.BytesPerReq }),
+		GCPauseP50Ms: medianOf(func(m memoryStats) *float64 { return m.GCPauseP50Ms }),
+		GCPauseP99Ms: medianOf(func(m memoryStats) *float64 { return m.GCPauseP99Ms }),
+		GCPauseMaxMs: medianOf(func(m memoryStats) *float64 { return m.GCPauseMaxMs }),
+	}
+	for _, run := range runs {
+		if run.Memory.GCCount != nil {
+			total := *run.Memory.GCCount
+			if m.GCCount != nil {
+				total += *m.GCCount
+			}
+			m.GCCount = &total
+		}
+		m.PeakRSSLifetime = m.PeakRSSLifetime || run.Memory.PeakRSSLifetime
+	}
+	return m
+}
+
+// errorPct is the percentage of attempted requests across all runs that
+// failed or got an unexpected status.
+func (r *result) errorPct() float64 {
+	var attempted uint64
+	for _, run := range r.Runs {
+		attempted += run.Requests + run.Errors
+	}
+	if attempted == 0 {
+		return 0
+	}
+	return float64(r.Errors+r.BadStatus) / float64(attempted) * 100
+}
+
+func (r *result) label() string {
+	if r.Mode == "" {
+		return r.Server
+	}
+	return r.Server + " (" + r.Mode + ")"
+}
+
+// report is the document written to results/ as JSON and Markdown.
+type report struct {
+	GeneratedAt time.Time   `json:"generated_at"`
+	Commit      string      `json:"commit,omitempty"`
+	Config      Config      `json:"config"`
+	Results     []*result   `json:"results"`
+	Comparison  *comparison `json:"comparison,omitempty"`
+	// Interrupted marks a suite cut short by a signal. Its results are
+	// partial, so it is never picked as a regression baseline.
+	Interrupted bool `json:"interrupted,omitempty"`
+}
+
+func (rep *report) write(dir string) (jsonPath, mdPath string, err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", err
+	}
+	stamp := rep.GeneratedAt.Format(stampLayout)
+	jsonPath = filepath.Join(dir, "runner-"+stamp+".json")
+	mdPath = filepath.Join(dir, "runner-"+stamp+".md")
+
+	data, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(jsonPath, append(data, '\n'), 0o644); err != nil {
+		return "", "", err
+	}
+
+	f, err := os.Create(mdPath)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+	if err := rep.markdown(f); err != nil {
+		return "", "", err
+	}
+	return jsonPath, mdPath, f.Close()
+}
+
+func (rep *report) markdown(w io.Writer) error {
+	var b strings.Builder
+	cfg := rep.Config
+	fmt.Fprintf(&b, "# HTTP Framework Benchmark\n\n")
+	fmt.Fprintf(&b, "**Generated:** %s\n\n", rep.GeneratedAt.Format(time.RFC1123))
+	if rep.Commit != "" {
+		fmt.Fprintf(&b, "**Commit:** %s\n\n", rep.Commit)
+	}
+	if rep.Interrupted {
+		b.WriteString("**Interrupted:** results are partial and this report is not used as a regression baseline.\n\n")
+	}
+	fmt.Fprintf(&b, "## Configuration\n\n")
+	fmt.Fprintf(&b, "| Parameter | Value |\n|-----------|-------|\n")
+	fmt.Fprintf(&b, "| Target | %s |\n", cfg.Target)
+	fmt.Fprintf(&b, "| Connections | %d |\n", cfg.Load.Connections)
+	fmt.Fprintf(&b, "| Pipeline | %d |\n", cfg.Load.Pipeline)
+	fmt.Fprintf(&b, "| Duration | %s |\n", cfg.Load.Duration)
+	fmt.Fprintf(&b, "| Runs | %d |\n", cfg.Runs)
+	fmt.Fprintf(&b, "| Warmup (AOT) | %s |\n", cfg.Load.Warmup)
+	fmt.Fprintf(&b, "| Warmup (JIT) | %s |\n\n", cfg.Load.WarmupJIT)
+
+	for _, sc := range cfg.Scenarios {
+		rows := rep.scenarioResults(sc.Name)
+		if len(rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "## %s\n\n", sc.Name)
+		fmt.Fprintf(&b, "`%s %s`\n\n", sc.Method, strings.Join(sc.Paths, "`, `"))
+		fmt.Fprintf(&b, "| Server | Median RPS | Min | Max | Avg | P50 | P99 | P99.9 | Errors | %% of %s |\n", cfg.Baseline)
+		fmt.Fprintf(&b, "|--------|------------|-----|-----|-----|-----|-----|-------|--------|------|\n")
+		for _, r := range rows {
+			if r.Error != "" {
+				fmt.Fprintf(&b, "| %s | failed: %s | | | | | | | | |\n", r.label(), r.Error)
+				continue
+			}
+			fmt.Fprintf(&b, "| %s | **%.0f** | %.0f | %.0f | %.2fms | %.2fms | %.2fms | %.2fms | %d | %s |\n",
+				r.label(), r.RPS.Median, r.RPS.Min, r.RPS.Max,
+				r.Latency.MeanMs, r.Latency.P50Ms, r.Latency.P99Ms, r.Latency.P999Ms,
+				r.Errors+r.BadStatus, rep.relative(r))
+		}
+		b.WriteString("\n")
+		writeMemoryTable(&b, rows)
+	}
+
+	if rep.Comparison != nil {
+		rep.Comparison.markdown(&b, cfg.Regression)
+	}
+
+	b.WriteString("## Reproduction\n\n```bash\ncd benchmarks/runner && go run .\n```\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMemoryTable adds the profiling columns for a scenario when at least
+// one server reported them. GCs is the total across runs.
+func writeMemoryTable(b *strings.Builder, rows []*result) {
+	profiled := false
+	for _, r := range rows {
+		if r.Memory.PeakRSSMB != nil || r.Memory.GCCount != nil {
+			profiled = true
+			break
+		}
+	}
+	if !profiled {
+		return
+	}
+	lifetime, dartAOT := false, false
+	fmt.Fprintf(b, "| Server | Peak RSS | Allocs/req | Bytes/req | GCs | GC p50 | GC p99 | GC max |\n")
+	fmt.Fprintf(b, "|--------|----------|------------|-----------|-----|--------|--------|--------|\n")
+	for _, r := range rows {
+		if r.Error != "" {
+			continue
+		}
+		m := r.Memory
+		if spec, _ := lookupServer(r.Server); spec.kind == kindDart && r.Mode == modeAOT && m.AllocsPerReq == nil {
+			dartAOT = true
+		}
+		peakFormat := "%.1fMB"
+		if m.PeakRSSLifetime {
+			peakFormat += "*"
+			lifetime = true
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s | %s | %s | %s |\n", r.label(),
+			optional(m.PeakRSSMB, peakFormat), optional(m.AllocsPerReq, "%.1f"), optional(m.BytesPerReq, "%.0f"),
+			optional(m.GCCount, "%d"), optional(m.GCPauseP50Ms, "%.3fms"), optional(m.GCPauseP99Ms, "%.3fms"),
+			optional(m.GCPauseMaxMs, "%.3fms"))
+	}
+	b.WriteString("\n")
+	if lifetime {
+		b.WriteString("\\* Peak since the server started; the runner could not reset it before the run.\n\n")
+	}
+	if dartAOT {
+		b.WriteString("Dart AOT executables have no VM service, so their allocation and GC figures are not recorded.\n\n")
+	}
+}
+
+func optional[T any](v *T, format string) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf(format, *v)
+}
+
+// scenarioResults returns the results for a scenario, fastest first.
+func (rep *report) scenarioResults(name string) []*result {
+	var rows []*result
+	for _, r := range rep.Results {
+		if r.Scenario == name {
+			rows = append(rows, r)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].RPS.Median > rows[j].RPS.Median })
+	return rows
+}
+
+// relative expresses r's median RPS as a percentage of the baseline server
+// in the same mode, falling back to the baseline's AOT run for servers that
+// have no mode.
+func (rep *report) relative(r *result) string {
+	var base *result
+	for _, candidate := range rep.Results {
+		if candidate.Server != rep.Config.Baseline || candidate.Scenario != r.Scenario || candidate.Error != "" {
+			continue
+		}
+		if candidate.Mode == r.Mode {
+			base = candidate
+			break
+		}
+		if r.Mode == "" && candidate.Mode == modeAOT {
+			base = candidate
+		}
+	}
+	if base == nil || base.RPS.Median == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", r.RPS.Median/base.RPS.Median*100)
+}
+
+func median(sorted []float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[len(sorted)/2]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
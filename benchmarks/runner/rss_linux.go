@@ -0,0 +1,86 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// resetPeakRSS clears the kernel's resident-memory high-water mark (VmHWM)
+// for every process in pgid, so the next processGroupPeakRSS covers only
+// what happens afterwards. It reports false when any process could not be
+// reset; the peak then spans the process lifetime.
+func resetPeakRSS(pgid int) bool {
+	pids := processGroup(pgid)
+	if len(pids) == 0 {
+		return false
+	}
+	ok := true
+	for _, pid := range pids {
+		// Writing 5 to clear_refs resets VmHWM (Linux 4.0+).
+		if err := os.WriteFile(filepath.Join("/proc", pid, "clear_refs"), []byte("5"), 0); err != nil {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// processGroupPeakRSS sums the resident-memory high-water mark across every
+// process in pgid, so a launcher and the server it spawns are measured
+// together. The kernel tracks the peak, so short spikes are not missed.
+func processGroupPeakRSS(pgid int) (uint64, bool) {
+	var total uint64
+	found := false
+	for _, pid := range processGroup(pgid) {
+		data, err := os.ReadFile(filepath.Join("/proc", pid, "status"))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			value, ok := strings.CutPrefix(line, "VmHWM:")
+			if !ok {
+				continue
+			}
+			// The value is reported in kB.
+			kb, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+			if err == nil {
+				total += kb << 10
+				found = true
+			}
+			break
+		}
+	}
+	return total, found
+}
+
+// processGroup lists the PIDs in process group pgid.
+func processGroup(pgid int) []string {
+	if pgid <= 0 {
+		return nil
+	}
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		return nil
+	}
+	var pids []string
+	for _, statPath := range stats {
+		data, err := os.ReadFile(statPath)
+		if err != nil {
+			continue
+		}
+		// The command name may contain spaces; fields resume after ')'.
+		end := strings.LastIndexByte(string(data), ')')
+		if end < 0 {
+			continue
+		}
+		fields := strings.Fields(string(data[end+1:]))
+		// fields[0] is the state, fields[2] the process group.
+		if len(fields) >= 3 && fields[2] == strconv.Itoa(pgid) {
+			pids = append(pids, filepath.Base(filepath.Dir(statPath)))
+		}
+	}
+	return pids
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package main
+
+// resetPeakRSS and processGroupPeakRSS are only implemented on Linux;
+// elsewhere the profiler falls back to the max_rss_bytes each server
+// reports from /debug/stats.
+func resetPeakRSS(int) bool {
+	return false
+}
+
+func processGroupPeakRSS(int) (uint64, bool) {
+	return 0, false
+}
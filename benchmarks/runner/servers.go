@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+const (
+	modeAOT = "aot"
+	modeJIT = "jit"
+)
+
+type serverKind int
+
+const (
+	kindDart serverKind = iota
+	kindGo
+	kindPython
+)
+
+// serverSpec describes one directory under benchmarks/servers.
+type serverSpec struct {
+	name string
+	port int
+	kind serverKind
+}
+
+// servers lists every benchmark server in report order: the dart:io
+// baseline first, then frameworks, routed last among the Dart servers,
+// followed by the Go routers (net/http ServeMux first) and FastAPI.
+var servers = []serverSpec{
+	{"dart_io", 8001, kindDart},
+	{"relic", 8007, kindDart},
+	{"serinus", 8003, kindDart},
+	{"shelf", 8002, kindDart},
+	{"routed", 8006, kindDart},
+	{"go", 8004, kindGo},
+	{"gin", 8008, kindGo},
+	{"echo", 8009, kindGo},
+	{"chi", 8010, kindGo},
+	{"fastapi", 8005, kindPython},
+}
+
+func serverNames() []string {
+	names := make([]string, len(servers))
+	for i, s := range servers {
+		names[i] = s.name
+	}
+	return names
+}
+
+func lookupServer(name string) (serverSpec, bool) {
+	for _, s := range servers {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return serverSpec{}, false
+}
+
+// modes returns the modes the server runs in. Only Dart servers have a
+// meaningful AOT/JIT split; everything else runs once with an empty mode.
+func (s serverSpec) modes(requested []string) []string {
+	if s.kind == kindDart {
+		return requested
+	}
+	return []string{""}
+}
+
+// service is the docker-compose.yml service that runs the server in mode
+// when the suite targets the compose stack.
+func (s serverSpec) service(mode string) string {
+	switch {
+	case s.kind == kindDart:
+		return s.name + "_" + mode
+	case s.name == "go":
+		return "go_server"
+	}
+	return s.name
+}
+
+// vmServicePort is where a profiled Dart JIT server exposes its VM service.
+func (s serverSpec) vmServicePort() int {
+	return s.port + 1000
+}
+
+func (s serverSpec) dir(root string) string {
+	return filepath.Join(root, "servers", s.name)
+}
+
+// build prepares the server for mode. It is a no-op for interpreted servers.
+func (s serverSpec) build(ctx context.Context, root, mode string) error {
+	dir := s.dir(root)
+	switch s.kind {
+	case kindDart:
+		if err := runQuiet(ctx, dir, "dart", "pub", "get"); err != nil {
+			return err
+		}
+		if mode == modeAOT {
+			return runQuiet(ctx, dir, "dart", "compile", "exe", "bin/server.dart", "-o", "bin/server")
+		}
+	case kindGo:
+		return runQuiet(ctx, dir, "go", "build", "-o", "bin/server", ".")
+	}
+	return nil
+}
+
+func (s serverSpec) command(root, host, mode string, profile bool) *exec.Cmd {
+	dir := s.dir(root)
+	var cmd *exec.Cmd
+	switch s.kind {
+	case kindDart:
+		if mode == modeAOT {
+			cmd = exec.Command(filepath.Join(dir, "bin", "server"))
+		} else if profile {
+			// The VM service is how the profiler reads Dart allocation and
+			// GC figures; auth codes are disabled so it is reachable at /ws.
+			cmd = exec.Command("dart", "run",
+				fmt.Sprintf("--enable-vm-service=%d/127.0.0.1", s.vmServicePort()),
+				"--disable-service-auth-codes", "bin/server.dart")
+		} else {
+			cmd = exec.Command("dart", "run", "bin/server.dart")
+		}
+	case kindGo:
+		cmd = exec.Command(filepath.Join(dir, "bin", "server"))
+	case kindPython:
+		uvicorn := "uvicorn"
+		if venv := filepath.Join(dir, ".venv", "bin", "uvicorn"); fileExists(venv) {
+			uvicorn = venv
+		}
+		cmd = exec.Command(uvicorn, "app:app", "--host", host, "--port", fmt.Sprint(s.port), "--log-level", "warning")
+	}
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), fmt.Sprintf("PORT=%d", s.port), "HOST="+host)
+	if profile {
+		cmd.Env = append(cmd.Env, "BENCH_PROFILE=1")
+	}
+	return cmd
+}
+
+// process is a running benchmark server.
+type process struct {
+	cmd  *exec.Cmd
+	done chan error
+}
+
+func (s serverSpec) start(root, host, mode string, profile bool) (*process, error) {
+	cmd := s.command(root, host, mode, profile)
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", s.name, err)
+	}
+	p := &process{cmd: cmd, done: make(chan error, 1)}
+	go func() { p.done <- cmd.Wait() }()
+	return p, nil
+}
+
+// waitReady polls url until it answers 200 or timeout elapses. exited
+// reports a local server dying early; it is nil for servers the runner did
+// not start.
+func waitReady(ctx context.Context, url string, timeout time.Duration, exited chan error) error {
+	client := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-exited:
+			exited <- err
+			return fmt.Errorf("server exited before becoming ready: %v", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return fmt.Errorf("server not responding on %s after %s", url, timeout)
+}
+
+// stop terminates the whole process group, escalating to SIGKILL when the
+// server ignores SIGTERM.
+func (p *process) stop() {
+	terminateProcessGroup(p.cmd, false)
+	select {
+	case <-p.done:
+	case <-time.After(5 * time.Second):
+		terminateProcessGroup(p.cmd, true)
+		<-p.done
+	}
+}
+
+func runQuiet(ctx context.Context, dir, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %v: %w\n%s", name, args, err, out)
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
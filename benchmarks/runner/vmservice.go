@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// gcPauseEvents are the timeline events the Dart VM records around each
+// stop-the-world collection. Concurrent marking and sweeping run outside
+// them and are not counted as pauses.
+var gcPauseEvents = map[string]bool{
+	"CollectNewGeneration": true,
+	"CollectOldGeneration": true,
+}
+
+// vmService is a JSON-RPC client for the Dart VM service of a JIT server
+// started with --enable-vm-service. It is how the runner gets allocation
+// and GC figures the Dart servers cannot report from /debug/stats.
+type vmService struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	nextID int
+}
+
+// dialVMService connects to the VM service WebSocket at addr. The server
+// must run with --disable-service-auth-codes so the endpoint is /ws.
+func dialVMService(ctx context.Context, addr string) (*vmService, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	v := &vmService{conn: conn, br: bufio.NewReader(conn)}
+	if err := v.handshake(addr); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("vm service %s: %w", addr, err)
+	}
+	// Record GC events so each run can read its pauses off the timeline.
+	if err := v.call("setVMTimelineFlags", map[string]any{"recordedStreams": []string{"GC"}}, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *vmService) handshake(addr string) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	_ = v.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	defer v.conn.SetDeadline(time.Time{})
+	if _, err := fmt.Fprintf(v.conn, "GET /ws HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", addr, key); err != nil {
+		return err
+	}
+	resp, err := http.ReadResponse(v.br, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return fmt.Errorf("websocket upgrade returned %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		return errors.New("websocket upgrade returned a bad Sec-WebSocket-Accept")
+	}
+	return nil
+}
+
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC11B85"))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// call sends one request and decodes its result into result, skipping
+// any stream notifications that arrive in between.
+func (v *vmService) call(method string, params map[string]any, result any) error {
+	v.nextID++
+	id := fmt.Sprint(v.nextID)
+	req, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	_ = v.conn.SetDeadline(time.Now().Add(30 * time.Second))
+	defer v.conn.SetDeadline(time.Time{})
+	if err := v.writeFrame(req); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	for {
+		msg, err := v.readMessage()
+		if err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+		var resp struct {
+			ID     string          `json:"id"`
+			Result json.RawMessage `json:"result"`
+			Error  *struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+		if resp.ID != id {
+			continue
+		}
+		if resp.Error != nil {
+			return fmt.Errorf("%s: %s (%d)", method, resp.Error.Message, resp.Error.Code)
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+}
+
+// writeFrame sends payload as one masked text frame, as RFC 6455 requires
+// of clients.
+func (v *vmService) writeFrame(payload []byte) error {
+	header := []byte{0x81, 0}
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	header[1] |= 0x80
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	frame := append(header, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := v.conn.Write(frame)
+	return err
+}
+
+// readMessage returns the next complete text message, reassembling
+// fragments and skipping control frames.
+func (v *vmService) readMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(v.br, head[:]); err != nil {
+			return nil, err
+		}
+		fin, opcode := head[0]&0x80 != 0, head[0]&0x0f
+		n := uint64(head[1] & 0x7f)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(v.br, ext[:]); err != nil {
+				return nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(v.br, ext[:]); err != nil {
+				return nil, err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		var mask []byte
+		if head[1]&0x80 != 0 {
+			mask = make([]byte, 4)
+			if _, err := io.ReadFull(v.br, mask); err != nil {
+				return nil, err
+			}
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(v.br, payload); err != nil {
+			return nil, err
+		}
+		if mask != nil {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+		switch opcode {
+		case 0x8:
+			return nil, errors.New("vm service closed the connection")
+		case 0x0, 0x1, 0x2:
+			msg = append(msg, payload...)
+			if fin {
+				return msg, nil
+			}
+		}
+	}
+}
+
+func (v *vmService) close() {
+	v.conn.Close()
+}
+
+// isolates lists the ids of the VM's non-system isolates.
+func (v *vmService) isolates() ([]string, error) {
+	var vm struct {
+		Isolates []struct {
+			ID string `json:"id"`
+		} `json:"isolates"`
+	}
+	if err := v.call("getVM", nil, &vm); err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(vm.Isolates))
+	for i, iso := range vm.Isolates {
+		ids[i] = iso.ID
+	}
+	return ids, nil
+}
+
+// begin resets the allocation accumulators and clears the timeline so the
+// next collect covers only the measured run.
+func (v *vmService) begin() error {
+	ids, err := v.isolates()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := v.call("getAllocationProfile", map[string]any{"isolateId": id, "reset": true}, nil); err != nil {
+			return err
+		}
+	}
+	return v.call("clearVMTimeline", nil, nil)
+}
+
+// vmRunStats is what the VM service reports about one measured run.
+type vmRunStats struct {
+	Allocs     uint64
+	AllocBytes uint64
+	GCPauseNs  []uint64
+}
+
+// collect sums allocations across isolates since begin and reads the GC
+// pauses recorded on the timeline.
+func (v *vmService) collect() (*vmRunStats, error) {
+	ids, err := v.isolates()
+	if err != nil {
+		return nil, err
+	}
+	var stats vmRunStats
+	for _, id := range ids {
+		var profile struct {
+			Members []struct {
+				InstancesAccumulated uint64 `json:"instancesAccumulated"`
+				AccumulatedSize      uint64 `json:"accumulatedSize"`
+			} `json:"members"`
+		}
+		if err := v.call("getAllocationProfile", map[string]any{"isolateId": id}, &profile); err != nil {
+			return nil, err
+		}
+		for _, m := range profile.Members {
+			stats.Allocs += m.InstancesAccumulated
+			stats.AllocBytes += m.AccumulatedSize
+		}
+	}
+	var timeline struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	if err := v.call("getVMTimeline", nil, &timeline); err != nil {
+		return nil, err
+	}
+	stats.GCPauseNs = gcPauses(timeline.TraceEvents)
+	return &stats, nil
+}
+
+// traceEvent is one event in Chrome trace format, which getVMTimeline
+// returns. Timestamps and durations are in microseconds.
+type traceEvent struct {
+	Name  string  `json:"name"`
+	Phase string  `json:"ph"`
+	TID   int64   `json:"tid"`
+	TS    float64 `json:"ts"`
+	Dur   float64 `json:"dur"`
+}
+
+// gcPauses extracts pause durations from complete ("X") events and from
+// begin/end pairs.
+func gcPauses(events []traceEvent) []uint64 {
+	events = append([]traceEvent(nil), events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].TS < events[j].TS })
+	var pauses []uint64
+	open := make(map[string]float64)
+	for _, e := range events {
+		if !gcPauseEvents[e.Name] {
+			continue
+		}
+		key := fmt.Sprint(e.TID, e.Name)
+		switch e.Phase {
+		case "X":
+			pauses = append(pauses, uint64(e.Dur*1000))
+		case "B":
+			open[key] = e.TS
+		case "E":
+			if start, ok := open[key]; ok {
+				pauses = append(pauses, uint64((e.TS-start)*1000))
+				delete(open, key)
+			}
+		}
+	}
+	return pauses
+}
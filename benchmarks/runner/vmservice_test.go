@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeVMService answers JSON-RPC calls over a WebSocket the way the Dart VM
+// service does, sending each result unmasked and unfragmented.
+func fakeVMService(t *testing.T, results map[string]any) (addr string, calls *[]string) {
+	t.Helper()
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ws" {
+			http.NotFound(w, r)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + websocketAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+		for {
+			payload, err := readClientFrame(rw.Reader)
+			if err != nil {
+				return
+			}
+			var req struct {
+				ID     string         `json:"id"`
+				Method string         `json:"method"`
+				Params map[string]any `json:"params"`
+			}
+			if err := json.Unmarshal(payload, &req); err != nil {
+				t.Error(err)
+				return
+			}
+			call := req.Method
+			if req.Params["reset"] == true {
+				call += "(reset)"
+			}
+			seen = append(seen, call)
+			// A stream notification ahead of the response must be skipped.
+			note, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "method": "streamNotify", "params": map[string]any{}})
+			writeServerFrame(rw, note)
+			resp, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": results[req.Method]})
+			writeServerFrame(rw, resp)
+			rw.Flush()
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://"), &seen
+}
+
+func readClientFrame(r *bufio.Reader) ([]byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, err
+	}
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(r, ext[:])
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(r, ext[:])
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(r, mask[:]); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return payload, nil
+}
+
+func writeServerFrame(w io.Writer, payload []byte) {
+	header := []byte{0x81}
+	if len(payload) < 126 {
+		header = append(header, byte(len(payload)))
+	} else {
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	}
+	w.Write(header)
+	w.Write(payload)
+}
+
+func TestVMServiceRun(t *testing.T) {
+	addr, calls := fakeVMService(t, map[string]any{
+		"getVM": map[string]any{"isolates": []map[string]any{{"id": "isolates/1"}}},
+		"getAllocationProfile": map[string]any{"members": []map[string]any{
+			{"instancesAccumulated": 300, "accumulatedSize": 9000},
+			{"instancesAccumulated": 100, "accumulatedSize": 1000},
+		}},
+		"getVMTimeline": map[string]any{"traceEvents": []map[string]any{
+			{"name": "CollectNewGeneration", "ph": "X", "ts": 10, "dur": 250},
+			{"name": "Scavenge", "ph": "X", "ts": 11, "dur": 200},
+			{"name": "CollectOldGeneration", "ph": "B", "tid": 2, "ts": 1000},
+			{"name": "CollectOldGeneration", "ph": "E", "tid": 2, "ts": 3000},
+		}},
+	})
+	vm, err := dialVMService(context.Background(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vm.close()
+	if err := vm.begin(); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := vm.collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &vmRunStats{Allocs: 400, AllocBytes: 10000, GCPauseNs: []uint64{250_000, 2_000_000}}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("collect = %+v, want %+v", stats, want)
+	}
+	wantCalls := []string{"setVMTimelineFlags", "getVM", "getAllocationProfile(reset)", "clearVMTimeline",
+		"getVM", "getAllocationProfile", "getVMTimeline"}
+	if !reflect.DeepEqual(*calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", *calls, wantCalls)
+	}
+
+	var m memoryStats
+	m.fromVM(stats, 100)
+	if *m.AllocsPerReq != 4 || *m.BytesPerReq != 100 || *m.GCCount != 2 || *m.GCPauseMaxMs != 2 {
+		t.Errorf("fromVM = allocs %v, bytes %v, GCs %v, max %vms", *m.AllocsPerReq, *m.BytesPerReq, *m.GCCount, *m.GCPauseMaxMs)
+	}
+}
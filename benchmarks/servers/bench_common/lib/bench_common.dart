@@ -0,0 +1,30 @@
+/// Payloads and profiling helpers shared by the Dart benchmark servers, so
+/// every server answers the large-payload scenarios and `/debug/stats` the
+/// same way. The Go servers share the equivalent `bench` package.
+library;
+
+import 'dart:io';
+import 'dart:typed_data';
+
+/// Body length for the large-payload scenarios. Downloads are written in
+/// [downloadChunk]-sized pieces rather than buffered.
+const downloadSize = 1 << 20;
+
+final downloadChunk = Uint8List(64 << 10)..fillRange(0, 64 << 10, 0x78);
+
+/// The `/download` body as a stream of [downloadChunk]s.
+Stream<List<int>> downloadStream() => Stream.fromIterable(
+  List.filled(downloadSize ~/ downloadChunk.length, downloadChunk),
+);
+
+/// Whether the server was started with BENCH_PROFILE=1. Servers only mount
+/// `/debug/stats` then, so the default route table matches the other servers.
+final profiling = Platform.environment['BENCH_PROFILE'] == '1';
+
+/// Process memory snapshot served at `/debug/stats` when BENCH_PROFILE=1.
+/// dart:io only exposes RSS; allocation and GC counters need the VM service.
+Map<String, Object> debugStats() => {
+  'runtime': 'dart',
+  'rss_bytes': ProcessInfo.currentRss,
+  'max_rss_bytes': ProcessInfo.maxRss,
+};
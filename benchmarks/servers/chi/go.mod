@@ -0,0 +1,9 @@
+module benchmarks/chi_server
+
+go 1.22
+
+require benchmarks/go_server v0.0.0
+
+require github.com/go-chi/chi/v5 v5.1.0
+
+replace benchmarks/go_server => ../go
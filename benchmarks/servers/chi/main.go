@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"benchmarks/go_server/bench"
+	"github.com/go-chi/chi/v5"
+)
+
+func main() {
+	r := chi.NewRouter()
+	for _, sc := range bench.Scenarios {
+		wildcard := bench.Wildcard(sc.Path)
+		r.MethodFunc(sc.Method, pattern(sc.Path), func(w http.ResponseWriter, req *http.Request) {
+			sc.Handle(w, req, func(name string) string {
+				// chi exposes the catch-all under "*" rather than its name.
+				if name == wildcard {
+					name = "*"
+				}
+				return chi.URLParam(req, name)
+			})
+		})
+	}
+	registerRoutes(r, bench.GitHubAPI)
+	registerRoutes(r, bench.ExtraRoutes)
+	if bench.Profiling() {
+		r.Handle("/debug/*", bench.Debug())
+	}
+
+	addr := bench.Addr("8010")
+	log.Printf("chi server listening on http://%s (%d routes)", addr,
+		len(bench.Scenarios)+len(bench.GitHubAPI)+len(bench.ExtraRoutes))
+	log.Fatal(http.ListenAndServe(addr, r))
+}
+
+func registerRoutes(r chi.Router, routes []bench.Route) {
+	for _, rt := range routes {
+		r.MethodFunc(rt.Method, pattern(rt.Path), bench.WriteOK)
+	}
+}
+
+// pattern rewrites a ServeMux path into chi syntax: `{name}` parameters
+// carry over, and a trailing `{name...}` becomes `*`.
+func pattern(path string) string {
+	return bench.Convert(path, func(name string, wildcard bool) string {
+		if wildcard {
+			return "*"
+		}
+		return "{" + name + "}"
+	})
+}
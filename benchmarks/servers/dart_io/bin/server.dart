@@ -1,6 +1,10 @@
 import 'dart:convert';
 import 'dart:io';
 
+import 'package:bench_common/bench_common.dart';
+
+import 'route_table.dart';
+
 Future<void> main() async {
   final port = int.tryParse(Platform.environment['PORT'] ?? '') ?? 8001;
   final host = Platform.environment['HOST'] ?? '0.0.0.0';
@@ -10,14 +14,50 @@ Future<void> main() async {
     shared: true,
   );
 
-  server.listen((HttpRequest request) {
+  server.listen((HttpRequest request) async {
     final response = request.response;
-    if (request.uri.path == '/json') {
+    final method = request.method;
+    final path = request.uri.path;
+    if (method == 'POST' && path == '/echo') {
+      // Errors thrown here would escape the listen callback as unhandled
+      // async errors, so malformed bodies are answered explicitly.
+      try {
+        final payload = jsonDecode(await utf8.decoder.bind(request).join());
+        response.headers.contentType = ContentType.json;
+        response.write(jsonEncode(payload));
+      } on FormatException catch (e) {
+        response.statusCode = HttpStatus.badRequest;
+        response.headers.contentType = ContentType.text;
+        response.write(e.message);
+      }
+    } else if (method == 'POST' && path == '/upload') {
+      final length = await request.fold<int>(0, (n, chunk) => n + chunk.length);
+      response.headers.contentType = ContentType.text;
+      response.write('$length');
+    } else if (method == 'GET' && path == '/download') {
+      response.headers.contentType = ContentType.binary;
+      response.contentLength = downloadSize;
+      for (var written = 0;
+          written < downloadSize;
+          written += downloadChunk.length) {
+        response.add(downloadChunk);
+      }
+    } else if (method == 'GET' && path == '/json') {
       response.headers.contentType = ContentType.json;
       response.write(jsonEncode({"ok": true}));
+    } else if (profiling && method == 'GET' && path == '/debug/stats') {
+      response.headers.contentType = ContentType.json;
+      response.write(jsonEncode(debugStats()));
     } else {
+      final segments = request.uri.pathSegments;
+      final body =
+          route(method, path, segments) ??
+          (matchesRouteTable(method, segments) ? 'ok' : null);
+      if (body == null) {
+        response.statusCode = HttpStatus.notFound;
+      }
       response.headers.contentType = ContentType.text;
-      response.write('ok');
+      response.write(body ?? 'Not Found');
     }
     response.close();
   });
@@ -25,3 +65,70 @@ Future<void> main() async {
   // ignore: avoid_print
   print('dart_io listening on http://$host:$port');
 }
+
+/// Hand-written matcher for the shared routing scenarios, mirroring what the
+/// framework servers register: every route is GET-only, so other methods
+/// fall through to the 404. Returns null when nothing matches.
+String? route(String method, String path, List<String> segments) {
+  if (method != 'GET') {
+    return null;
+  }
+  if (path == '/' || path == '/deep/a/b/c/d/e/f/g/h') {
+    return 'ok';
+  }
+  if (path.startsWith('/static/')) {
+    return path.substring('/static/'.length);
+  }
+  if (segments.length == 2 && segments[0] == 'param') {
+    return segments[1];
+  }
+  if (segments.length == 6 && segments[0] == 'params') {
+    return segments.skip(1).join('/');
+  }
+  return null;
+}
+
+/// [routeTable] patterns split into segments once, keyed by method.
+final _tableByMethod = () {
+  final table = <String, List<List<String>>>{};
+  for (final (method, path) in routeTable) {
+    table.putIfAbsent(method, () => []).add(path.substring(1).split('/'));
+  }
+  return table;
+}();
+
+/// Linear scan over the shared route table, the way a hand-written
+/// dispatcher without a router would do it. `{name}` matches one non-empty
+/// segment and a trailing `{name...}` matches the rest of the path.
+bool matchesRouteTable(String method, List<String> segments) {
+  final patterns = _tableByMethod[method];
+  if (patterns == null) {
+    return false;
+  }
+  outer:
+  for (final pattern in patterns) {
+    for (var i = 0; i < pattern.length; i++) {
+      final part = pattern[i];
+      if (part.endsWith('...}')) {
+        if (i < segments.length) {
+          return true;
+        }
+        continue outer;
+      }
+      if (i >= segments.length) {
+        continue outer;
+      }
+      if (part.startsWith('{')) {
+        if (segments[i].isEmpty) {
+          continue outer;
+        }
+      } else if (part != segments[i]) {
+        continue outer;
+      }
+    }
+    if (pattern.length == segments.length) {
+      return true;
+    }
+  }
+  return false;
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"benchmarks/go_server/bench"
+	"github.com/labstack/echo/v4"
+)
+
+func main() {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	for _, sc := range bench.Scenarios {
+		wildcard := bench.Wildcard(sc.Path)
+		e.Add(sc.Method, pattern(sc.Path), func(c echo.Context) error {
+			sc.Handle(c.Response(), c.Request(), func(name string) string {
+				// Echo exposes the catch-all under "*" rather than its name.
+				if name == wildcard {
+					name = "*"
+				}
+				return c.Param(name)
+			})
+			return nil
+		})
+	}
+	registerRoutes(e, bench.GitHubAPI)
+	registerRoutes(e, bench.ExtraRoutes)
+	if bench.Profiling() {
+		e.Any("/debug/*", echo.WrapHandler(bench.Debug()))
+	}
+
+	addr := bench.Addr("8009")
+	log.Printf("echo server listening on http://%s (%d routes)", addr,
+		len(bench.Scenarios)+len(bench.GitHubAPI)+len(bench.ExtraRoutes))
+	log.Fatal(e.Start(addr))
+}
+
+func registerRoutes(e *echo.Echo, routes []bench.Route) {
+	handler := echo.WrapHandler(http.HandlerFunc(bench.WriteOK))
+	for _, rt := range routes {
+		e.Add(rt.Method, pattern(rt.Path), handler)
+	}
+}
+
+// pattern rewrites a ServeMux path into Echo's `:name` / `*` syntax.
+func pattern(path string) string {
+	return bench.Convert(path, func(name string, wildcard bool) string {
+		if wildcard {
+			return "*"
+		}
+		return ":" + name
+	})
+}
@@ -1,7 +1,24 @@
-from fastapi import FastAPI
+import collections
+import gc
+import os
+import resource
+import sys
+import time
+
+from typing import Any
+
+from fastapi import FastAPI, Request
+from fastapi.exceptions import RequestValidationError
+from fastapi.responses import PlainTextResponse, StreamingResponse
+
+from route_table import ROUTE_TABLE
 
 app = FastAPI()
 
+# Body length for the large-payload scenarios; downloads stream in chunks.
+DOWNLOAD_SIZE = 1 << 20
+DOWNLOAD_CHUNK = b"x" * (64 << 10)
+
 
 @app.get("/")
 def root():
@@ -11,3 +28,102 @@ def root():
 @app.get("/json")
 def json_endpoint():
     return {"ok": True}
+
+
+@app.get("/deep/a/b/c/d/e/f/g/h", response_class=PlainTextResponse)
+def deep_static():
+    return "ok"
+
+
+@app.get("/param/{id}", response_class=PlainTextResponse)
+def single_param(id: str):
+    return id
+
+
+@app.get("/params/{a}/{b}/{c}/{d}/{e}", response_class=PlainTextResponse)
+def five_params(a: str, b: str, c: str, d: str, e: str):
+    return f"{a}/{b}/{c}/{d}/{e}"
+
+
+@app.get("/static/{path:path}", response_class=PlainTextResponse)
+def catch_all(path: str):
+    return path
+
+
+@app.post("/echo")
+def echo(payload: dict[str, Any]):
+    return payload
+
+
+@app.exception_handler(RequestValidationError)
+async def bad_request(_request: Request, exc: RequestValidationError):
+    # FastAPI answers bodies that fail to bind with 422; the other servers
+    # reject malformed JSON with 400.
+    return PlainTextResponse(str(exc), status_code=400)
+
+
+@app.post("/upload", response_class=PlainTextResponse)
+async def upload(request: Request):
+    length = 0
+    async for chunk in request.stream():
+        length += len(chunk)
+    return str(length)
+
+
+@app.get("/download")
+def download():
+    chunks = (DOWNLOAD_CHUNK for _ in range(DOWNLOAD_SIZE // len(DOWNLOAD_CHUNK)))
+    return StreamingResponse(
+        chunks,
+        media_type="application/octet-stream",
+        headers={"Content-Length": str(DOWNLOAD_SIZE)},
+    )
+
+
+def table_route():
+    return "ok"
+
+
+for method, path in ROUTE_TABLE:
+    app.add_api_route(path, table_route, methods=[method], response_class=PlainTextResponse)
+
+
+if os.environ.get("BENCH_PROFILE") == "1":
+    # Time every collection so the runner can report GC pause percentiles.
+    _gc_pauses = collections.deque(maxlen=256)
+    _gc_started = [0]
+    _gc_count = [0]
+
+    def _time_gc(phase, _info):
+        if phase == "start":
+            _gc_started[0] = time.perf_counter_ns()
+        else:
+            _gc_pauses.append(time.perf_counter_ns() - _gc_started[0])
+            _gc_count[0] += 1
+
+    gc.callbacks.append(_time_gc)
+
+    def _rss_bytes():
+        try:
+            with open("/proc/self/statm") as f:
+                return int(f.read().split()[1]) * os.sysconf("SC_PAGE_SIZE")
+        except OSError:
+            return None
+
+    def _max_rss_bytes():
+        # ru_maxrss is in kilobytes on Linux and bytes on macOS.
+        peak = resource.getrusage(resource.RUSAGE_SELF).ru_maxrss
+        return peak if sys.platform == "darwin" else peak * 1024
+
+    @app.get("/debug/stats")
+    def debug_stats():
+        stats = {
+            "runtime": "python",
+            "max_rss_bytes": _max_rss_bytes(),
+            "num_gc": _gc_count[0],
+            "gc_pause_ns": list(_gc_pauses),
+        }
+        rss = _rss_bytes()
+        if rss is not None:
+            stats["rss_bytes"] = rss
+        return stats
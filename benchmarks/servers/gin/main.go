@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"benchmarks/go_server/bench"
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	for _, sc := range bench.Scenarios {
+		r.Handle(sc.Method, pattern(sc.Path), func(c *gin.Context) {
+			sc.Handle(c.Writer, c.Request, func(name string) string {
+				// Gin includes the leading slash in catch-all values.
+				return strings.TrimPrefix(c.Param(name), "/")
+			})
+		})
+	}
+	registerRoutes(r, bench.GitHubAPI)
+	registerRoutes(r, bench.ExtraRoutes)
+	if bench.Profiling() {
+		r.Any("/debug/*any", gin.WrapH(bench.Debug()))
+	}
+
+	addr := bench.Addr("8008")
+	log.Printf("gin server listening on http://%s (%d routes)", addr,
+		len(bench.Scenarios)+len(bench.GitHubAPI)+len(bench.ExtraRoutes))
+	log.Fatal(http.ListenAndServe(addr, r))
+}
+
+func registerRoutes(r *gin.Engine, routes []bench.Route) {
+	for _, rt := range routes {
+		r.Handle(rt.Method, pattern(rt.Path), gin.WrapF(bench.WriteOK))
+	}
+}
+
+// pattern rewrites a ServeMux path into Gin's `:name` / `*name` syntax.
+func pattern(path string) string {
+	return bench.Convert(path, func(name string, wildcard bool) string {
+		if wildcard {
+			return "*" + name
+		}
+		return ":" + name
+	})
+}
@@ -0,0 +1,111 @@
+package bench
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Profiling reports whether the server was started with BENCH_PROFILE=1.
+// Servers only mount Debug then, so the default route table matches the
+// other servers.
+func Profiling() bool {
+	return os.Getenv("BENCH_PROFILE") == "1"
+}
+
+// Debug serves pprof and a /debug/stats snapshot for the benchmark runner.
+// It expects full request paths, so routers mount it under /debug/ without
+// stripping the prefix.
+func Debug() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /debug/stats", writeStats)
+	return mux
+}
+
+// debugStats is the payload shared by every benchmark server's /debug/stats.
+type debugStats struct {
+	Runtime         string   `json:"runtime"`
+	RSSBytes        *uint64  `json:"rss_bytes,omitempty"`
+	MaxRSSBytes     *uint64  `json:"max_rss_bytes,omitempty"`
+	HeapBytes       uint64   `json:"heap_bytes"`
+	TotalAllocBytes uint64   `json:"total_alloc_bytes"`
+	Mallocs         uint64   `json:"mallocs"`
+	NumGC           uint64   `json:"num_gc"`
+	GCPauseNs       []uint64 `json:"gc_pause_ns"`
+}
+
+func writeStats(w http.ResponseWriter, _ *http.Request) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	// PauseNs is a ring of the last 256 pauses; GC k lives at (k+255)%256.
+	n := uint64(ms.NumGC)
+	if n > uint64(len(ms.PauseNs)) {
+		n = uint64(len(ms.PauseNs))
+	}
+	pauses := make([]uint64, 0, n)
+	for k := uint64(ms.NumGC) - n + 1; k <= uint64(ms.NumGC); k++ {
+		pauses = append(pauses, ms.PauseNs[(k+255)%256])
+	}
+
+	stats := debugStats{
+		Runtime:         "go",
+		RSSBytes:        readRSS(),
+		MaxRSSBytes:     readMaxRSS(),
+		HeapBytes:       ms.HeapAlloc,
+		TotalAllocBytes: ms.TotalAlloc,
+		Mallocs:         ms.Mallocs,
+		NumGC:           uint64(ms.NumGC),
+		GCPauseNs:       pauses,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stats)
+}
+
+// readRSS reports resident memory from /proc/self/statm, or nil where
+// procfs is unavailable.
+func readRSS() *uint64 {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return nil
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return nil
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return nil
+	}
+	rss := pages * uint64(os.Getpagesize())
+	return &rss
+}
+
+// readMaxRSS reports the resident-memory high-water mark (VmHWM) from
+// /proc/self/status, or nil where procfs is unavailable.
+func readMaxRSS() *uint64 {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "VmHWM:"); ok {
+			kb, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+			if err != nil {
+				return nil
+			}
+			rss := kb << 10
+			return &rss
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package bench
+
+import (
+	"net"
+	"os"
+)
+
+// Addr is the listen address from HOST and PORT, defaulting to all
+// interfaces on defaultPort.
+func Addr(defaultPort string) string {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = defaultPort
+	}
+	host := os.Getenv("HOST")
+	if host == "" {
+		host = "0.0.0.0"
+	}
+	return net.JoinHostPort(host, port)
+}
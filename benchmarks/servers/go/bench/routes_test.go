@@ -0,0 +1,80 @@
+package bench
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	colon := func(name string, wildcard bool) string {
+		if wildcard {
+			return "*" + name
+		}
+		return ":" + name
+	}
+	for _, tc := range []struct {
+		path, want string
+	}{
+		{"/", "/"},
+		{"/authorizations", "/authorizations"},
+		{"/authorizations/{id}", "/authorizations/:id"},
+		{"/repos/{owner}/{repo}/git/refs/{ref...}", "/repos/:owner/:repo/git/refs/*ref"},
+		{"/files/{bucket}/{path...}", "/files/:bucket/*path"},
+		// Only whole segments are parameters.
+		{"/a{b}/c", "/a{b}/c"},
+	} {
+		if got := Convert(tc.path, colon); got != tc.want {
+			t.Errorf("Convert(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestWildcard(t *testing.T) {
+	for path, want := range map[string]string{
+		"/static/{path...}":                       "path",
+		"/repos/{owner}/{repo}/git/refs/{ref...}": "ref",
+		"/param/{id}":                             "",
+		"/json":                                   "",
+	} {
+		if got := Wildcard(path); got != want {
+			t.Errorf("Wildcard(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// TestRouteTable checks the invariants the other routers rely on: every
+// route is unique, catch-alls only end a path, and sibling parameters share
+// a name, which Gin and the Dart routers require.
+func TestRouteTable(t *testing.T) {
+	routes := append(append([]Route(nil), GitHubAPI...), ExtraRoutes...)
+	seen := make(map[string]bool)
+	params := make(map[string]string)
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		key := rt.Method + " " + rt.Path
+		if seen[key] {
+			t.Errorf("duplicate route %s", key)
+		}
+		seen[key] = true
+		if !strings.HasPrefix(rt.Path, "/") {
+			t.Errorf("%s does not start with /", key)
+		}
+		segments := strings.Split(rt.Path, "/")
+		for i, seg := range segments {
+			if !strings.HasPrefix(seg, "{") {
+				continue
+			}
+			if strings.HasSuffix(seg, "...}") && i != len(segments)-1 {
+				t.Errorf("%s has a catch-all before the end", key)
+			}
+			prefix := strings.Join(segments[:i], "/")
+			if name, ok := params[prefix]; ok && name != seg {
+				t.Errorf("%s names %s where a sibling uses %s", key, seg, name)
+			}
+			params[prefix] = seg
+		}
+		// ServeMux panics on conflicting patterns.
+		mux.HandleFunc(key, WriteOK)
+	}
+}
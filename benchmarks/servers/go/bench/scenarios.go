@@ -0,0 +1,91 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// downloadSize is the body length for the large-payload scenarios; the
+// download is written in downloadChunk-sized pieces rather than buffered.
+const downloadSize = 1 << 20
+
+var downloadChunk = bytes.Repeat([]byte("x"), 64<<10)
+
+// Params looks up a matched path parameter by the name used in the
+// ServeMux-style pattern, including the name of a `{name...}` catch-all.
+type Params func(name string) string
+
+// Scenario is one route every server under benchmarks/servers implements
+// identically.
+type Scenario struct {
+	Method string
+	Path   string
+	Handle func(w http.ResponseWriter, r *http.Request, params Params)
+}
+
+// Scenarios are the plaintext, JSON, routing, and payload scenarios.
+// Parameterised handlers echo what they matched so each router has to
+// extract the values. Anything else falls through to the router's 404 for
+// the miss-storm scenario.
+var Scenarios = []Scenario{
+	{"GET", "/", func(w http.ResponseWriter, r *http.Request, _ Params) {
+		WriteOK(w, r)
+	}},
+	{"GET", "/json", func(w http.ResponseWriter, _ *http.Request, _ Params) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}},
+	{"GET", "/deep/a/b/c/d/e/f/g/h", func(w http.ResponseWriter, r *http.Request, _ Params) {
+		WriteOK(w, r)
+	}},
+	{"GET", "/param/{id}", func(w http.ResponseWriter, _ *http.Request, p Params) {
+		writeText(w, p("id"))
+	}},
+	{"GET", "/params/{a}/{b}/{c}/{d}/{e}", func(w http.ResponseWriter, _ *http.Request, p Params) {
+		writeText(w, strings.Join([]string{p("a"), p("b"), p("c"), p("d"), p("e")}, "/"))
+	}},
+	{"GET", "/static/{path...}", func(w http.ResponseWriter, _ *http.Request, p Params) {
+		writeText(w, p("path"))
+	}},
+	{"POST", "/echo", func(w http.ResponseWriter, r *http.Request, _ Params) {
+		echoJSON(w, r)
+	}},
+	{"POST", "/upload", func(w http.ResponseWriter, r *http.Request, _ Params) {
+		n, err := io.Copy(io.Discard, r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeText(w, strconv.FormatInt(n, 10))
+	}},
+	{"GET", "/download", func(w http.ResponseWriter, _ *http.Request, _ Params) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(downloadSize))
+		for written := 0; written < downloadSize; written += len(downloadChunk) {
+			if _, err := w.Write(downloadChunk); err != nil {
+				return
+			}
+		}
+	}},
+}
+
+// echoJSON decodes the request body and serializes it back, so the scenario
+// pays for both directions of JSON handling.
+func echoJSON(w http.ResponseWriter, r *http.Request) {
+	var payload any
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+func writeText(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(body))
+}
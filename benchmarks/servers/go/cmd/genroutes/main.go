@@ -0,0 +1,100 @@
+// Command genroutes writes the shared route table (bench.GitHubAPI plus
+// bench.ExtraRoutes) into the non-Go benchmark servers in each router's path
+// syntax, so the github scenario hits the same routes everywhere it runs.
+// Serinus is left out: its catch-all syntax is unconfirmed, so the scenario
+// skips it.
+//
+// Run it through go generate in benchmarks/servers/go/bench after editing
+// the table.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"benchmarks/go_server/bench"
+)
+
+const header = "Code generated by genroutes from benchmarks/servers/go/bench; DO NOT EDIT."
+
+// target is one generated file and how its router spells parameters.
+type target struct {
+	path   string
+	syntax string
+	lang   string
+	param  func(name string, wildcard bool) string
+}
+
+var targets = []target{
+	{"dart_io/bin/route_table.dart", "net/http ServeMux", "dart", func(name string, wildcard bool) string {
+		if wildcard {
+			return "{" + name + "...}"
+		}
+		return "{" + name + "}"
+	}},
+	{"relic/bin/route_table.dart", "relic", "dart", func(name string, wildcard bool) string {
+		if wildcard {
+			return "**"
+		}
+		return ":" + name
+	}},
+	{"shelf/bin/route_table.dart", "shelf_router", "dart", func(name string, wildcard bool) string {
+		if wildcard {
+			return "<" + name + "|.*>"
+		}
+		return "<" + name + ">"
+	}},
+	{"routed/bin/route_table.dart", "routed", "dart", func(name string, wildcard bool) string {
+		if wildcard {
+			return "{*" + name + "}"
+		}
+		return "{" + name + "}"
+	}},
+	{"fastapi/route_table.py", "FastAPI", "python", func(name string, wildcard bool) string {
+		if wildcard {
+			return "{" + name + ":path}"
+		}
+		return "{" + name + "}"
+	}},
+}
+
+func main() {
+	root := flag.String("servers", "..", "benchmarks/servers directory")
+	flag.Parse()
+	for _, t := range targets {
+		path := filepath.Join(*root, t.path)
+		if err := os.WriteFile(path, t.render(), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func (t target) render() []byte {
+	routes := append(append([]bench.Route(nil), bench.GitHubAPI...), bench.ExtraRoutes...)
+	var b bytes.Buffer
+	switch t.lang {
+	case "dart":
+		fmt.Fprintf(&b, "// %s\n\n", header)
+		fmt.Fprintf(&b, "/// The GitHub API route table and extra catch-all routes the Go servers\n")
+		fmt.Fprintf(&b, "/// register, in %s path syntax.\n", t.syntax)
+		b.WriteString("const routeTable = <(String, String)>[\n")
+		for _, rt := range routes {
+			fmt.Fprintf(&b, "  ('%s', '%s'),\n", rt.Method, bench.Convert(rt.Path, t.param))
+		}
+		b.WriteString("];\n")
+	case "python":
+		fmt.Fprintf(&b, "# %s\n", header)
+		fmt.Fprintf(&b, "\"\"\"The GitHub API route table and extra catch-all routes the Go servers\n")
+		fmt.Fprintf(&b, "register, in %s path syntax.\"\"\"\n\n", t.syntax)
+		b.WriteString("ROUTE_TABLE = [\n")
+		for _, rt := range routes {
+			fmt.Fprintf(&b, "    (\"%s\", \"%s\"),\n", rt.Method, bench.Convert(rt.Path, t.param))
+		}
+		b.WriteString("]\n")
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGeneratedFilesUpToDate fails when the route table changed without
+// rerunning go generate.
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	for _, tg := range targets {
+		path := filepath.Join("..", "..", "..", tg.path)
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, tg.render()) {
+			t.Errorf("%s is stale; run go generate ./bench in benchmarks/servers/go", path)
+		}
+	}
+}
@@ -1,34 +1,39 @@
 package main
 
 import (
-	"fmt"
 	"log"
 	"net/http"
-	"os"
+
+	"benchmarks/go_server/bench"
 )
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8004"
-	}
-	host := os.Getenv("HOST")
-	if host == "" {
-		host = "0.0.0.0"
-	}
-
 	mux := http.NewServeMux()
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path == "/json" {
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = w.Write([]byte(`{"ok":true}`))
-			return
+	for _, sc := range bench.Scenarios {
+		pattern := sc.Path
+		if pattern == "/" {
+			pattern = "/{$}"
 		}
-		w.Header().Set("Content-Type", "text/plain")
-		_, _ = w.Write([]byte("ok"))
-	})
+		mux.HandleFunc(sc.Method+" "+pattern, func(w http.ResponseWriter, r *http.Request) {
+			sc.Handle(w, r, r.PathValue)
+		})
+	}
+	registerRoutes(mux, bench.GitHubAPI)
+	registerRoutes(mux, bench.ExtraRoutes)
+	if bench.Profiling() {
+		mux.Handle("/debug/", bench.Debug())
+	}
 
-	addr := fmt.Sprintf("%s:%s", host, port)
-	log.Printf("go server listening on http://%s", addr)
+	addr := bench.Addr("8004")
+	log.Printf("go server listening on http://%s (%d routes)", addr,
+		len(bench.Scenarios)+len(bench.GitHubAPI)+len(bench.ExtraRoutes))
 	log.Fatal(http.ListenAndServe(addr, mux))
 }
+
+// registerRoutes adds every route in the table to mux. Paths are already in
+// ServeMux syntax.
+func registerRoutes(mux *http.ServeMux, routes []bench.Route) {
+	for _, rt := range routes {
+		mux.HandleFunc(rt.Method+" "+rt.Path, bench.WriteOK)
+	}
+}
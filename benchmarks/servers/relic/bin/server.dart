@@ -1,9 +1,12 @@
 import 'dart:convert';
 import 'dart:io';
 
+import 'package:bench_common/bench_common.dart';
 import 'package:relic/io_adapter.dart';
 import 'package:relic/relic.dart';
 
+import 'route_table.dart';
+
 Future<void> main() async {
   final port = int.tryParse(Platform.environment['PORT'] ?? '') ?? 8007;
   final host = Platform.environment['HOST'] ?? '0.0.0.0';
@@ -15,7 +18,31 @@ Future<void> main() async {
   final app =
       RelicApp()
         ..get('/', _ok)
-        ..get('/json', _json);
+        ..get('/json', _json)
+        ..get('/deep/a/b/c/d/e/f/g/h', _ok)
+        ..get('/param/:id', _param)
+        ..get('/params/:a/:b/:c/:d/:e', _params)
+        ..get('/static/**', _static)
+        ..post('/echo', _echo)
+        ..post('/upload', _upload)
+        ..get('/download', _download);
+  for (final (method, path) in routeTable) {
+    switch (method) {
+      case 'GET':
+        app.get(path, _ok);
+      case 'POST':
+        app.post(path, _ok);
+      case 'PUT':
+        app.put(path, _ok);
+      case 'PATCH':
+        app.patch(path, _ok);
+      case 'DELETE':
+        app.delete(path, _ok);
+    }
+  }
+  if (profiling) {
+    app.get('/debug/stats', _stats);
+  }
 
   await app.serve(address: address, port: port, shared: true);
 }
@@ -29,3 +56,60 @@ Response _json(Request request) {
     body: Body.fromString(jsonEncode({"ok": true}), mimeType: MimeType.json),
   );
 }
+
+Response _param(Request request) {
+  return _text(request.rawPathParameters[#id]!);
+}
+
+Response _params(Request request) {
+  final params = request.rawPathParameters;
+  return _text(
+    '${params[#a]}/${params[#b]}/${params[#c]}/${params[#d]}/${params[#e]}',
+  );
+}
+
+Response _static(Request request) {
+  return _text(request.remainingPath.toString().substring(1));
+}
+
+Future<Response> _echo(Request request) async {
+  final Object? payload;
+  try {
+    payload = jsonDecode(await request.readAsString());
+  } on FormatException catch (e) {
+    return Response.badRequest(
+      body: Body.fromString(e.message, mimeType: MimeType.plainText),
+    );
+  }
+  return Response.ok(
+    body: Body.fromString(jsonEncode(payload), mimeType: MimeType.json),
+  );
+}
+
+Future<Response> _upload(Request request) async {
+  final length = await request.read().fold<int>(
+    0,
+    (n, chunk) => n + chunk.length,
+  );
+  return _text('$length');
+}
+
+Response _download(Request request) {
+  return Response.ok(
+    body: Body.fromDataStream(
+      downloadStream(),
+      mimeType: MimeType.octetStream,
+      contentLength: downloadSize,
+    ),
+  );
+}
+
+Response _text(String body) {
+  return Response.ok(body: Body.fromString(body, mimeType: MimeType.plainText));
+}
+
+Response _stats(Request request) {
+  return Response.ok(
+    body: Body.fromString(jsonEncode(debugStats()), mimeType: MimeType.json),
+  );
+}
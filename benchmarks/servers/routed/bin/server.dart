@@ -1,7 +1,10 @@
 import 'dart:io';
 
+import 'package:bench_common/bench_common.dart';
 import 'package:routed/routed.dart';
 
+import 'route_table.dart';
+
 Future<void> main() async {
   final host = Platform.environment['HOST'] ?? '0.0.0.0';
   final port = int.tryParse(Platform.environment['PORT'] ?? '8006') ?? 8006;
@@ -20,5 +23,61 @@ Future<void> main() async {
     return ctx.json({"ok": true});
   });
 
+  engine.get('/deep/a/b/c/d/e/f/g/h', (ctx) {
+    return ctx.string('ok');
+  });
+
+  engine.get('/param/{id}', (ctx) {
+    return ctx.string(ctx.param('id') as String);
+  });
+
+  engine.get('/params/{a}/{b}/{c}/{d}/{e}', (ctx) {
+    final p = ctx.params;
+    return ctx.string('${p['a']}/${p['b']}/${p['c']}/${p['d']}/${p['e']}');
+  });
+
+  engine.get('/static/{*path}', (ctx) {
+    return ctx.string(ctx.param('path') as String);
+  });
+
+  engine.post('/echo', (ctx) async {
+    // bindJSON rejects malformed JSON with a 400 JsonParseError; a body that
+    // is not UTF-8 fails to decode before that.
+    final Map<String, Object?> payload;
+    try {
+      payload = await ctx.bindJSON(<String, Object?>{});
+    } on FormatException catch (e) {
+      return ctx.string(e.message, statusCode: HttpStatus.badRequest);
+    }
+    return ctx.json(payload);
+  });
+
+  engine.post('/upload', (ctx) async {
+    final length = await ctx.request.stream.fold<int>(
+      0,
+      (n, chunk) => n + chunk.length,
+    );
+    return ctx.string('$length');
+  });
+
+  engine.get('/download', (ctx) {
+    return ctx.dataFromReader(
+      statusCode: HttpStatus.ok,
+      contentLength: downloadSize,
+      contentType: 'application/octet-stream',
+      reader: downloadStream(),
+    );
+  });
+
+  for (final (method, path) in routeTable) {
+    engine.handle(method, path, (ctx) => ctx.string('ok'));
+  }
+
+  if (profiling) {
+    engine.get('/debug/stats', (ctx) {
+      return ctx.json(debugStats());
+    });
+  }
+
   await engine.serve(host: host, port: port, echo: false);
 }
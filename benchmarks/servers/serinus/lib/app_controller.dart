@@ -1,8 +1,39 @@
+import 'dart:typed_data';
+
+import 'package:bench_common/bench_common.dart';
 import 'package:serinus/serinus.dart';
 
 class AppController extends Controller {
   AppController() : super('/') {
     on(Route.get('/'), (context) async => 'ok');
     on(Route.get('/json'), (context) async => {'ok': true});
+    on(Route.get('/deep/a/b/c/d/e/f/g/h'), (context) async => 'ok');
+    on(Route.get('/param/<id>'), (context) async => context.params['id']);
+    on(
+      Route.get('/params/<a>/<b>/<c>/<d>/<e>'),
+      (context) async => const ['a', 'b', 'c', 'd', 'e']
+          .map((name) => context.params[name])
+          .join('/'),
+    );
+    on(
+      Route.get('/static/*'),
+      (context) async => context.request.uri.path.substring('/static/'.length),
+    );
+    // Serinus parses request bodies before the handler runs, so upload and
+    // echo measure its buffered body pipeline rather than a stream.
+    on(Route.post('/echo'), (context) async => context.body);
+    on(
+      Route.post('/upload'),
+      (context) async => '${(context.body as Uint8List).length}',
+    );
+    on(Route.get('/download'), (context) async => downloadBody);
+    if (profiling) {
+      on(Route.get('/debug/stats'), (context) async => debugStats());
+    }
   }
 }
+
+/// Body returned by `/download`. Serinus cannot stream a handler result, so
+/// the 1MB payload is allocated once and reused.
+final downloadBody = Uint8List(downloadSize)
+  ..fillRange(0, downloadSize, 0x78);
@@ -1,10 +1,13 @@
 import 'dart:convert';
 import 'dart:io';
 
+import 'package:bench_common/bench_common.dart';
 import 'package:shelf/shelf.dart';
 import 'package:shelf/shelf_io.dart' as shelf_io;
 import 'package:shelf_router/shelf_router.dart';
 
+import 'route_table.dart';
+
 Future<void> main() async {
   final port = int.tryParse(Platform.environment['PORT'] ?? '') ?? 8002;
   final host = Platform.environment['HOST'] ?? '0.0.0.0';
@@ -16,7 +19,52 @@ Future<void> main() async {
         (Request request) => Response.ok(
               jsonEncode({"ok": true}),
               headers: {HttpHeaders.contentTypeHeader: 'application/json'},
+            ))
+    ..get('/deep/a/b/c/d/e/f/g/h', (Request request) => Response.ok('ok'))
+    ..get('/param/<id>', (Request request, String id) => Response.ok(id))
+    ..get(
+        '/params/<a>/<b>/<c>/<d>/<e>',
+        (Request request, String a, String b, String c, String d, String e) =>
+            Response.ok('$a/$b/$c/$d/$e'))
+    ..get('/static/<path|.*>',
+        (Request request, String path) => Response.ok(path))
+    ..post('/echo', (Request request) async {
+      final Object? payload;
+      try {
+        payload = jsonDecode(await request.readAsString());
+      } on FormatException catch (e) {
+        return Response.badRequest(body: e.message);
+      }
+      return Response.ok(
+        jsonEncode(payload),
+        headers: {HttpHeaders.contentTypeHeader: 'application/json'},
+      );
+    })
+    ..post('/upload', (Request request) async {
+      final length =
+          await request.read().fold<int>(0, (n, chunk) => n + chunk.length);
+      return Response.ok('$length');
+    })
+    ..get(
+        '/download',
+        (Request request) => Response.ok(
+              downloadStream(),
+              headers: {
+                HttpHeaders.contentTypeHeader: 'application/octet-stream',
+                HttpHeaders.contentLengthHeader: '$downloadSize',
+              },
+            ));
+  for (final (method, path) in routeTable) {
+    router.add(method, path, (Request request) => Response.ok('ok'));
+  }
+  if (profiling) {
+    router.get(
+        '/debug/stats',
+        (Request request) => Response.ok(
+              jsonEncode(debugStats()),
+              headers: {HttpHeaders.contentTypeHeader: 'application/json'},
             ));
+  }
 
   final handler = const Pipeline().addHandler(router);
 
@@ -43,6 +43,26 @@ void main() {
         });
     });
 
+    test('Malformed JSON body responds with 400', () async {
+      final engine = testEngine();
+      engine.post('/json', (ctx) async {
+        final data = await ctx.bindJSON(<String, dynamic>{});
+        return ctx.json(data);
+      });
+
+      client = TestClient(RoutedRequestHandler(engine));
+
+      final response = await client.post(
+        '/json',
+        '{"name": "test",',
+        headers: {
+          'Content-Type': ['application/json'],
+        },
+      );
+
+      response.assertStatus(400);
+    });
+
     test('Form URL Encoded Binding', () async {
       final engine = testEngine();
 